@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	// Connects to players.go: Strategies pick clash.Card from the hand
+	"github.com/fiskie/go-clash/clash"
+)
+
+// Opponent is a strategy that plays cards for one side of a match. Decide is called on
+// every battle tick with the side's random source and returns the move to make, or
+// false to wait.
+type Opponent interface {
+	Name() string
+	Decide(state *GameState, side Side, rng *rand.Rand) (Move, bool)
+}
+
+// cycleProvider is implemented by opponents that bring their own card cycle instead of
+// shuffling the opponent's deck
+type cycleProvider interface {
+	Cycle() *CardCycle
+}
+
+// opponentStrategies lists the strategies a match can be played against
+var opponentStrategies = []string{"random", "elixir", "counter", "scripted"}
+
+// difficulties maps the AI difficulty levels to the strategy that plays them
+var difficulties = map[string]string{"easy": "random", "normal": "elixir", "hard": "counter"}
+
+// defaultDifficulty is used when no difficulty is chosen
+const defaultDifficulty = "normal"
+
+// difficultyStrategy returns the strategy of a difficulty level
+func difficultyStrategy(level string) (string, error) {
+	if strategy, exists := difficulties[level]; exists {
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown difficulty %q (available: easy, normal, hard)", level)
+}
+
+// newOpponent creates a strategy by name. The scripted strategy replays the player's side
+// of a previous match.
+func newOpponent(name string, recorded *ReplayData) (Opponent, error) {
+	switch name {
+	case "random":
+		return &RandomOpponent{}, nil
+	case "elixir":
+		return &ElixirOpponent{}, nil
+	case "counter":
+		return &CounterOpponent{}, nil
+	case "scripted":
+		if recorded == nil || len(recorded.Player.Opening) == 0 {
+			return nil, fmt.Errorf("no recorded match to replay")
+		}
+		return newScriptedOpponent(recorded.Player.Opening, recorded.Plays, SidePlayer), nil
+	}
+	return nil, fmt.Errorf("unknown opponent strategy %q (available: %s)", name, strings.Join(opponentStrategies, ", "))
+}
+
+// RandomOpponent plays a random card from its hand every five seconds when it can afford
+// it, and skips its turn otherwise
+type RandomOpponent struct {
+	next time.Duration
+}
+
+func (o *RandomOpponent) Name() string {
+	return "random"
+}
+
+func (o *RandomOpponent) Decide(state *GameState, side Side, rng *rand.Rand) (Move, bool) {
+	if state.Elapsed < o.next {
+		return Move{}, false
+	}
+	o.next = state.Elapsed + 5*time.Second
+
+	hand := state.cycle(side).Hand
+	if len(hand) == 0 {
+		return Move{}, false
+	}
+	slot := rng.Intn(len(hand))
+	if float64(lookupCardStats(hand[slot].Name).ElixirCost) > state.elixir(side) {
+		return Move{}, false
+	}
+	return Move{Slot: slot, Lane: Lane(rng.Intn(2))}, true
+}
+
+// ElixirOpponent picks a card and a lane, then waits until it can afford the card before
+// playing it. It pauses briefly between plays like a human would.
+type ElixirOpponent struct {
+	card     string
+	lane     Lane
+	earliest time.Duration
+}
+
+func (o *ElixirOpponent) Name() string {
+	return "elixir"
+}
+
+func (o *ElixirOpponent) Decide(state *GameState, side Side, rng *rand.Rand) (Move, bool) {
+	if state.Elapsed < o.earliest {
+		return Move{}, false
+	}
+	cycle := state.cycle(side)
+	if len(cycle.Hand) == 0 {
+		return Move{}, false
+	}
+	if cycle.Slot(o.card) < 0 {
+		o.card = cycle.Hand[rng.Intn(len(cycle.Hand))].Name
+		o.lane = Lane(rng.Intn(2))
+	}
+	if float64(lookupCardStats(o.card).ElixirCost) > state.elixir(side) {
+		return Move{}, false
+	}
+
+	move := Move{Slot: cycle.Slot(o.card), Lane: o.lane}
+	o.card = ""
+	o.earliest = state.Elapsed + time.Second
+	return move, true
+}
+
+// counterCards maps a card to the cards that answer it well
+var counterCards = map[string][]string{
+	"Giant":            {"Mini P.E.K.K.A", "P.E.K.K.A", "Inferno Tower", "Cannon", "Tombstone", "Knight"},
+	"Hog Rider":        {"Cannon", "Tombstone", "Mini P.E.K.K.A", "Knight", "Ice Spirit"},
+	"Battle Ram":       {"Cannon", "Tombstone", "Knight", "Mini P.E.K.K.A"},
+	"Balloon":          {"Musketeer", "Archers", "Minions", "Mega Minion", "Electro Wizard"},
+	"Lava Hound":       {"Musketeer", "Minions", "Mega Minion", "Archers"},
+	"Elixir Golem":     {"P.E.K.K.A", "Mini P.E.K.K.A", "Knight", "Tombstone"},
+	"P.E.K.K.A":        {"Skeletons", "Minions", "Tombstone", "Knight"},
+	"Mini P.E.K.K.A":   {"Skeletons", "Knight", "Minions", "Tombstone"},
+	"Knight":           {"Mini P.E.K.K.A", "P.E.K.K.A", "Skeletons", "Minions"},
+	"Musketeer":        {"Fireball", "Lightning", "Knight", "Mini P.E.K.K.A"},
+	"Archers":          {"Arrows", "Log", "Fireball", "Zap"},
+	"Minions":          {"Arrows", "Zap", "Musketeer", "Archers", "Fire Spirit"},
+	"Goblin Barrel":    {"Log", "Arrows", "Zap", "Barbarian Barrel"},
+	"Goblin Gang":      {"Log", "Arrows", "Zap", "Fire Spirit"},
+	"Skeletons":        {"Log", "Zap", "Arrows", "Fire Spirit"},
+	"Bandit":           {"Knight", "Mini P.E.K.K.A", "Zap"},
+	"Royal Ghost":      {"Knight", "Mini P.E.K.K.A", "Skeletons"},
+	"Electro Wizard":   {"Fireball", "Lightning", "Poison", "Knight"},
+	"Magic Archer":     {"Fireball", "Lightning", "Poison"},
+	"Baby Dragon":      {"Musketeer", "Archers", "Fireball"},
+	"Elixir Collector": {"Lightning", "Fireball", "Poison", "Hog Rider"},
+	"Furnace":          {"Lightning", "Poison", "Fireball"},
+	"Tombstone":        {"Log", "Arrows", "Fireball"},
+	"Cannon":           {"Lightning", "Fireball", "Giant"},
+}
+
+// CounterOpponent answers the last card the other side played with a counter from its
+// hand, in the same lane. When there is nothing to answer it plays like ElixirOpponent.
+type CounterOpponent struct {
+	ElixirOpponent
+	answered int // Number of plays already looked at
+	counter  string
+	lane     Lane
+	deadline time.Duration // Give up on an unaffordable counter after this
+}
+
+func (o *CounterOpponent) Name() string {
+	return "counter"
+}
+
+func (o *CounterOpponent) Decide(state *GameState, side Side, rng *rand.Rand) (Move, bool) {
+	cycle := state.cycle(side)
+	for ; o.answered < len(state.Plays); o.answered++ {
+		play := state.Plays[o.answered]
+		if play.Side == side {
+			continue
+		}
+		for _, counter := range counterCards[play.Card.Name] {
+			if cycle.Slot(counter) >= 0 {
+				o.counter, o.lane, o.deadline = counter, play.Lane, state.Elapsed+3*time.Second
+				break
+			}
+		}
+	}
+
+	if o.counter != "" {
+		slot := cycle.Slot(o.counter)
+		if slot < 0 || state.Elapsed > o.deadline {
+			o.counter = ""
+		} else if float64(lookupCardStats(o.counter).ElixirCost) <= state.elixir(side) {
+			o.counter = ""
+			return Move{Slot: slot, Lane: o.lane}, true
+		} else {
+			// Save elixir for the counter
+			return Move{}, false
+		}
+	}
+	return o.ElixirOpponent.Decide(state, side, rng)
+}
+
+// ScriptedOpponent repeats the plays of one side of a recorded match. It deals the
+// recorded opening cycle so every scripted card comes up in the same order, and waits
+// for elixir when it is behind the recording.
+type ScriptedOpponent struct {
+	opening []clash.Card
+	plays   []CardPlay
+	next    int
+}
+
+// newScriptedOpponent scripts the plays a side made in a recorded match
+func newScriptedOpponent(opening []clash.Card, plays []CardPlay, side Side) *ScriptedOpponent {
+	var script []CardPlay
+	for _, play := range plays {
+		if play.Side == side {
+			script = append(script, play)
+		}
+	}
+	sort.SliceStable(script, func(i, j int) bool { return script[i].At < script[j].At })
+	return &ScriptedOpponent{opening: opening, plays: script}
+}
+
+func (o *ScriptedOpponent) Name() string {
+	return "scripted"
+}
+
+// Cycle deals the recorded opening order
+func (o *ScriptedOpponent) Cycle() *CardCycle {
+	return dealCardCycle(o.opening)
+}
+
+func (o *ScriptedOpponent) Decide(state *GameState, side Side, rng *rand.Rand) (Move, bool) {
+	for o.next < len(o.plays) {
+		play := o.plays[o.next]
+		if state.Elapsed < play.At {
+			return Move{}, false
+		}
+		slot := state.cycle(side).Slot(play.Card.Name)
+		if slot < 0 {
+			// The recording does not fit this deck, skip the play
+			o.next++
+			continue
+		}
+		if float64(lookupCardStats(play.Card.Name).ElixirCost) > state.elixir(side) {
+			return Move{}, false
+		}
+		o.next++
+		return Move{Slot: slot, Lane: play.Lane, Tile: play.Tile}, true
+	}
+	return Move{}, false
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpponent_Decide(t *testing.T) {
+	const anyLane = -1
+	type call struct {
+		at     time.Duration
+		elixir float64
+		card   string // Empty when the strategy should wait
+		lane   Lane
+	}
+	for _, tt := range []struct {
+		name     string
+		opponent Opponent
+		hand     []string
+		plays    []CardPlay // Made before the first call
+		calls    []call
+	}{
+		{"random", &RandomOpponent{}, []string{"Knight", "Knight", "Knight", "Knight"}, nil, []call{
+			{0, 10, "Knight", anyLane},
+			{4 * time.Second, 10, "", 0}, // Every five seconds
+			{5 * time.Second, 0, "", 0},  // Skips a turn it can't afford
+			{7 * time.Second, 10, "", 0},
+		}},
+		{"elixir", &ElixirOpponent{}, []string{"Giant", "Giant", "Giant", "Giant"}, nil, []call{
+			{0, 3, "", 0}, // Saves up for the Giant
+			{time.Second, 5, "Giant", anyLane},
+			{1500 * time.Millisecond, 10, "", 0}, // Pauses between plays
+			{2 * time.Second, 10, "Giant", anyLane},
+		}},
+		{"counter", &CounterOpponent{}, []string{"Giant", "Knight", "Arrows", "Skeletons"}, []CardPlay{
+			{Side: SidePlayer, Card: clash.Card{Name: "Knight"}, Lane: LaneRight},
+		}, []call{
+			{0, 1, "Skeletons", LaneRight},
+		}},
+		{"counter saves up", &CounterOpponent{}, []string{"Giant", "Knight", "Arrows", "Zap"}, []CardPlay{
+			{Side: SidePlayer, Card: clash.Card{Name: "Archers"}, Lane: LaneLeft},
+		}, []call{
+			{0, 1, "", 0},
+			{time.Second, 2, "Arrows", LaneLeft},
+		}},
+		{"counter gives up", &CounterOpponent{ElixirOpponent: ElixirOpponent{card: "Giant", lane: LaneRight}}, []string{"Giant", "Knight", "Arrows", "Zap"}, []CardPlay{
+			{Side: SidePlayer, Card: clash.Card{Name: "Archers"}, Lane: LaneLeft},
+		}, []call{
+			{0, 1, "", 0},
+			{4 * time.Second, 5, "Giant", LaneRight}, // Back to the card it was saving for
+		}},
+		{"scripted", newScriptedOpponent(nil, []CardPlay{
+			{Side: SideEnemy, Card: clash.Card{Name: "Giant"}, Lane: LaneLeft, Tile: &Tile{X: 3, Y: 2}, At: 4 * time.Second},
+			{Side: SideEnemy, Card: clash.Card{Name: "Knight"}, Lane: LaneRight, At: 2 * time.Second},
+			{Side: SideEnemy, Card: clash.Card{Name: "Hog Rider"}, Lane: LaneRight, At: 3 * time.Second},
+			{Side: SidePlayer, Card: clash.Card{Name: "Zap"}, Lane: LaneRight, At: 3 * time.Second},
+		}, SideEnemy), []string{"Knight", "Giant", "Arrows", "Zap"}, nil, []call{
+			{time.Second, 10, "", 0},
+			{2 * time.Second, 10, "Knight", LaneRight},
+			{4 * time.Second, 4, "", 0}, // Skips the Hog Rider it doesn't have and waits for elixir
+			{5 * time.Second, 5, "Giant", LaneLeft},
+			{6 * time.Second, 10, "", 0},
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var hand []clash.Card
+			for _, name := range tt.hand {
+				hand = append(hand, clash.Card{Name: name, Level: 1})
+			}
+			state := GameState{Rules: rulesets["ladder"], EnemyCycle: dealCardCycle(hand), Plays: tt.plays}
+			rng := rand.New(rand.NewSource(1))
+			for _, c := range tt.calls {
+				state.Elapsed, state.EnemyElixir = c.at, c.elixir
+				move, ok := tt.opponent.Decide(&state, SideEnemy, rng)
+				assert.Equal(t, c.card != "", ok, "at %s", c.at)
+				if !ok || c.card == "" {
+					continue
+				}
+				assert.Equal(t, c.card, hand[move.Slot].Name, "at %s", c.at)
+				if c.lane != anyLane {
+					assert.Equal(t, c.lane, move.Lane, "at %s", c.at)
+				}
+			}
+		})
+	}
+}
+
+func TestElixirOpponent_Seeded(t *testing.T) {
+	// A strategy's choices come from the source it is given, so a seed repeats them
+	deck := []clash.Card{{Name: "Giant"}, {Name: "Knight"}, {Name: "Arrows"}, {Name: "Zap"}, {Name: "Minions"}}
+	decide := func(seed int64) []Move {
+		state := GameState{Rules: rulesets["ladder"], EnemyCycle: dealCardCycle(deck), EnemyElixir: 10}
+		ai, rng := &ElixirOpponent{}, rand.New(rand.NewSource(seed))
+		var moves []Move
+		for i := 0; i < 10; i++ {
+			state.Elapsed = time.Duration(i) * time.Second
+			move, ok := ai.Decide(&state, SideEnemy, rng)
+			assert.True(t, ok)
+			moves = append(moves, move)
+		}
+		return moves
+	}
+	assert.Equal(t, decide(7), decide(7))
+}
+
+func TestNewOpponent(t *testing.T) {
+	for _, name := range []string{"random", "elixir", "counter"} {
+		opponent, err := newOpponent(name, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, name, opponent.Name())
+	}
+	_, err := newOpponent("scripted", nil)
+	assert.Error(t, err)
+	_, err = newOpponent("psychic", nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The arena is a grid of tiles like the real game: 18 tiles across and 32 from one back
+// line to the other. X runs from left to right; Y runs away from a side's own back line,
+// so every side places cards in its own coordinates.
+const (
+	arenaWidth  = 18
+	riverStart  = 15 // The river covers rows 15 and 16, the bridges cross it
+	riverEnd    = 16
+	pocketDepth = 6 // Rows past the river that open up when an enemy Guard Tower falls
+)
+
+// Tile is a square of the arena in the coordinates of the side placing a card
+type Tile struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (t Tile) String() string {
+	return fmt.Sprintf("%d,%d", t.X, t.Y)
+}
+
+// lane returns the lane a tile belongs to: the left half of the arena or the right
+func (t Tile) lane() Lane {
+	if t.X < arenaWidth/2 {
+		return LaneLeft
+	}
+	return LaneRight
+}
+
+// laneColumn is the column of a lane's bridge, where cards without a tile are dropped
+func laneColumn(lane Lane) int {
+	if lane == LaneRight {
+		return 14
+	}
+	return 3
+}
+
+// distance returns how far the middle of the tile is from the placing side's back line
+func (t Tile) distance() float64 {
+	return float64(t.Y) + 0.5
+}
+
+// parseTile converts user input such as "4,10" into a Tile
+func parseTile(s string) (Tile, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return Tile{}, fmt.Errorf("expected a tile as x,y, got %q", s)
+	}
+	x, errX := strconv.Atoi(strings.TrimSpace(parts[0]))
+	y, errY := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if errX != nil || errY != nil {
+		return Tile{}, fmt.Errorf("expected a tile as x,y, got %q", s)
+	}
+	return Tile{X: x, Y: y}, nil
+}
+
+// Move is a card a side wants to play: a hand slot (0-based) and where to put it.
+// Without a tile the card goes to the lane's default spot.
+type Move struct {
+	Slot int
+	Lane Lane
+	Tile *Tile
+}
+
+// towerFootprint returns the tiles a standing tower covers, in its own side's coordinates
+func towerFootprint(tower Tower) (x0, x1, y0, y1 int) {
+	if tower.isKing() {
+		return 7, 10, 1, 4
+	}
+	if tower.Lane == LaneRight {
+		return 13, 15, 5, 7
+	}
+	return 2, 4, 5, 7
+}
+
+// pocketOpen reports whether a side may deploy past the river in a lane, which it can
+// once the opponent's Guard Tower in that lane has fallen
+func pocketOpen(state *GameState, side Side, lane Lane) bool {
+	for _, tower := range state.towers(side.opposite()) {
+		if !tower.isKing() && tower.Lane == lane {
+			return tower.HP <= 0
+		}
+	}
+	return false
+}
+
+// checkPlacement enforces where a side may put a card. Spells can land anywhere in the
+// arena. Troops and buildings go on the side's own half, off its standing towers; once
+// an enemy Guard Tower falls the bridge and the pocket behind it in that lane open up.
+func checkPlacement(state *GameState, side Side, stats CardStats, tile Tile) error {
+	if tile.X < 0 || tile.X >= arenaWidth || tile.Y < 0 || tile.Y >= int(arenaLength) {
+		return fmt.Errorf("tile %s is outside the arena (x 0-%d, y 0-%d)", tile, arenaWidth-1, int(arenaLength)-1)
+	}
+	if stats.Type == CardSpell {
+		return nil
+	}
+
+	if tile.Y >= riverStart {
+		lane := tile.lane()
+		switch {
+		case !pocketOpen(state, side, lane) && tile.Y <= riverEnd:
+			return fmt.Errorf("tile %s is in the river", tile)
+		case !pocketOpen(state, side, lane):
+			return fmt.Errorf("tile %s is on the opponent's side; take their Guard Tower in the %s lane first", tile, lane)
+		case tile.Y > riverEnd+pocketDepth:
+			return fmt.Errorf("tile %s is past the pocket (up to y %d) in the %s lane", tile, riverEnd+pocketDepth, lane)
+		}
+		return nil
+	}
+
+	for _, tower := range state.towers(side) {
+		x0, x1, y0, y1 := towerFootprint(tower)
+		if tower.HP > 0 && tile.X >= x0 && tile.X <= x1 && tile.Y >= y0 && tile.Y <= y1 {
+			return fmt.Errorf("tile %s is taken by your %s", tile, tower.Type)
+		}
+	}
+	return nil
+}
+
+// placement describes where a card went for the replay
+func placement(lane Lane, tile *Tile) string {
+	if tile == nil {
+		return fmt.Sprintf("in the %s lane", lane)
+	}
+	return fmt.Sprintf("in the %s lane at %s", lane, tile)
+}
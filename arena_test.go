@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTile(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		tile  Tile
+		err   bool
+	}{
+		{"4,10", Tile{4, 10}, false},
+		{" 17, 0", Tile{17, 0}, false},
+		{"4", Tile{}, true},
+		{"4,10,2", Tile{}, true},
+		{"x,10", Tile{}, true},
+	} {
+		tile, err := parseTile(tt.input)
+		assert.Equal(t, tt.err, err != nil, tt.input)
+		assert.Equal(t, tt.tile, tile, tt.input)
+	}
+}
+
+func TestCheckPlacement(t *testing.T) {
+	troop, spell := lookupCardStats("Knight"), lookupCardStats("Fireball")
+	for _, tt := range []struct {
+		name   string
+		fallen []int // Enemy towers destroyed, as indexes into newTowers
+		stats  CardStats
+		tile   Tile
+		err    string // Empty when the card may go there
+	}{
+		{"own half", nil, troop, Tile{3, 10}, ""},
+		{"outside the arena", nil, troop, Tile{18, 10}, "tile 18,10 is outside the arena (x 0-17, y 0-31)"},
+		{"river", nil, troop, Tile{3, 15}, "tile 3,15 is in the river"},
+		{"opponent's side", nil, troop, Tile{3, 20}, "tile 3,20 is on the opponent's side; take their Guard Tower in the left lane first"},
+		{"on a guard tower", nil, troop, Tile{3, 6}, "tile 3,6 is taken by your Guard Tower 1"},
+		{"on the king tower", nil, troop, Tile{8, 2}, "tile 8,2 is taken by your King Tower"},
+		{"spells go anywhere", nil, spell, Tile{3, 28}, ""},
+		{"bridge once the guard falls", []int{0}, troop, Tile{3, 16}, ""},
+		{"pocket once the guard falls", []int{0}, troop, Tile{3, 22}, ""},
+		{"past the pocket", []int{0}, troop, Tile{3, 23}, "tile 3,23 is past the pocket (up to y 22) in the left lane"},
+		{"other lane stays closed", []int{0}, troop, Tile{14, 20}, "tile 14,20 is on the opponent's side; take their Guard Tower in the right lane first"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			state := GameState{PlayerTowers: newTowers(SidePlayer), EnemyTowers: newTowers(SideEnemy)}
+			for _, i := range tt.fallen {
+				state.EnemyTowers[i].HP = 0
+			}
+			err := checkPlacement(&state, SidePlayer, tt.stats, tt.tile)
+			if tt.err == "" {
+				assert.Nil(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestParseDeployCommand(t *testing.T) {
+	for _, tt := range []struct {
+		input  string
+		choice int
+		lane   Lane
+		tile   *Tile
+		err    bool
+	}{
+		{"2", 2, LaneLeft, nil, false},
+		{"2 r", 2, LaneRight, nil, false},
+		{"3 12,8", 3, LaneRight, &Tile{12, 8}, false},
+		{"3 left 4,8", 3, LaneLeft, &Tile{4, 8}, false},
+		{"3 left 12,8", 0, LaneLeft, nil, true}, // The tile is in the other lane
+		{"3 4", 0, LaneLeft, nil, true},
+		{"one", 0, LaneLeft, nil, true},
+		{"", 0, LaneLeft, nil, true},
+	} {
+		choice, lane, tile, err := parseDeployCommand(tt.input)
+		assert.Equal(t, tt.err, err != nil, tt.input)
+		assert.Equal(t, tt.choice, choice, tt.input)
+		assert.Equal(t, tt.lane, lane, tt.input)
+		assert.Equal(t, tt.tile, tile, tt.input)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	// Connects to players.go: Buildings are placed from a clash.Card
+	"github.com/fiskie/go-clash/clash"
+)
+
+// Buildings are placed between the princess towers and the river, where they pull
+// enemy troops crossing the lane
+const buildingDistance = 9.0
+
+// placeBuilding puts a building card into a lane, on a tile or at the default spot, and
+// returns a replay line describing it
+func placeBuilding(state *GameState, side Side, card clash.Card, stats CardStats, lane Lane, tile *Tile) string {
+	distance := buildingDistance
+	if tile != nil {
+		distance = tile.distance()
+	}
+	building := spawnUnits(state, side, card, stats, lane, sidePosition(side, distance), 1)[0]
+	building.spawn = stats.SpawnInterval
+	building.collect = stats.ElixirInterval
+	return fmt.Sprintf("%s built %s (Level %d) %s", state.sideName(side), card.Name, card.Level, placement(lane, tile))
+}
+
+// stepBuilding decays a building over its lifetime and runs its spawner and elixir
+// collector timers
+func stepBuilding(state *GameState, u *Unit, dt float64) []string {
+	var events []string
+	name := state.sideName(u.Side)
+
+	if u.Stats.SpawnCard != "" {
+		u.spawn -= dt
+		if u.spawn <= 0 {
+			u.spawn += u.Stats.SpawnInterval
+			spawned := clash.Card{Name: u.Stats.SpawnCard, Level: u.Card.Level}
+			spawnUnits(state, u.Side, spawned, lookupCardStats(spawned.Name), u.Lane, u.Pos, max(1, u.Stats.SpawnCount))
+		}
+	}
+
+	if u.Stats.ElixirInterval > 0 {
+		u.collect -= dt
+		if u.collect <= 0 {
+			u.collect += u.Stats.ElixirInterval
+			state.addElixir(u.Side, 1)
+			events = append(events, fmt.Sprintf("%s's %s produced 1 elixir", name, u.Card.Name))
+		}
+	}
+
+	if u.Stats.Lifetime > 0 {
+		u.decay += float64(u.MaxHP) * dt / u.Stats.Lifetime
+		lost := int(u.decay)
+		u.decay -= float64(lost)
+		u.HP -= lost
+		if u.HP <= 0 {
+			events = append(events, fmt.Sprintf("%s's %s expired", name, u.Card.Name))
+		}
+	}
+	return events
+}
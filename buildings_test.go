@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceBuilding(t *testing.T) {
+	state := GameState{PlayerName: "Player", Rules: rulesets["ladder"]}
+	card := clash.Card{Name: "Tombstone", Level: 1}
+	assert.Equal(t, "Player built Tombstone (Level 1) in the left lane",
+		placeBuilding(&state, SidePlayer, card, lookupCardStats(card.Name), LaneLeft, nil))
+	assert.Len(t, state.Units, 1)
+	assert.True(t, state.Units[0].isBuilding())
+	assert.Equal(t, buildingDistance, state.Units[0].Pos)
+
+	assert.Equal(t, "Player built Tombstone (Level 1) in the right lane at 12,4",
+		placeBuilding(&state, SidePlayer, card, lookupCardStats(card.Name), LaneRight, &Tile{X: 12, Y: 4}))
+	assert.Equal(t, 4.5, state.Units[1].Pos)
+}
+
+func TestStepBuilding(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		card   string
+		hp     int // Set before stepping, 0 keeps full health
+		dt     float64
+		after  int // HP after the step
+		units  int // Units in the arena after the step
+		elixir float64
+		events []string
+	}{
+		{"decays over its lifetime", "Cannon", 0, 3, 720, 1, 0, nil},
+		{"expires", "Cannon", 10, 1, -16, 1, 0, []string{"Player's Cannon expired"}},
+		{"spawner waits", "Tombstone", 0, 3, 450, 1, 0, nil},
+		{"spawner spawns", "Tombstone", 0, 3.5, 442, 2, 0, nil},
+		{"collector produces elixir", "Elixir Collector", 0, 9, 776, 1, 1, []string{"Player's Elixir Collector produced 1 elixir"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			state := GameState{PlayerName: "Player", Rules: rulesets["ladder"]}
+			card := clash.Card{Name: tt.card, Level: 1}
+			placeBuilding(&state, SidePlayer, card, lookupCardStats(card.Name), LaneLeft, nil)
+			building := state.Units[0]
+			if tt.hp > 0 {
+				building.HP = tt.hp
+			}
+			assert.Equal(t, tt.events, stepBuilding(&state, building, tt.dt))
+			assert.Equal(t, tt.after, building.HP)
+			assert.Len(t, state.Units, tt.units)
+			assert.Equal(t, tt.elixir, state.PlayerElixir)
+			if tt.units > 1 {
+				assert.Equal(t, "Skeletons", state.Units[1].Card.Name)
+				assert.Equal(t, LaneLeft, state.Units[1].Lane)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package clash
+
+import "context"
+
+// API is everything the Clash Royale API offers. Client talks to the live API and
+// MemoryAPI answers from structs held in memory, so applications can depend on API
+// and swap one for the other.
+type API interface {
+	Player(tag string) PlayerAPI
+	Players(tags ...string) PlayersAPI
+	Clan(tag string) ClanAPI
+	Clans() ClansAPI
+	ClansByTag(tags ...string) ClanBatchAPI
+	Location(id string) LocationAPI
+	Locations() LocationsAPI
+	Tournament(tag string) TournamentAPI
+	Tournaments() TournamentsAPI
+	Replay(tag string) ReplayAPI
+}
+
+// PlayerAPI is the endpoints of a single player
+type PlayerAPI interface {
+	Get() (Player, error)
+	UpcomingChests() (UpcomingChests, error)
+	BattleLog() (Battles, error)
+	VerifyToken(token string) (VerificationResult, error)
+}
+
+// PlayersAPI gets a batch of players concurrently
+type PlayersAPI interface {
+	GetAll(ctx context.Context) []BatchResult[Player]
+}
+
+// ClanAPI is the endpoints of a single clan
+type ClanAPI interface {
+	Get() (Clan, error)
+	Members() (MemberPager, error)
+	CurrentWar() (CurrentWar, error)
+	WarLog() (WarLogPager, error)
+}
+
+// ClansAPI searches all clans
+type ClansAPI interface {
+	Search(query *ClanQuery) (ClanPager, error)
+}
+
+// ClanBatchAPI gets a batch of clans concurrently
+type ClanBatchAPI interface {
+	GetAll(ctx context.Context) []BatchResult[Clan]
+}
+
+// LocationAPI is the endpoints of a single location
+type LocationAPI interface {
+	Get() (Location, error)
+	ClanRankings(query *PagedQuery) (LocationClanRankingPager, error)
+	PlayerRankings(query *PagedQuery) (LocationPlayerRankingPager, error)
+	ClanWarRankings(query *PagedQuery) (LocationClanRankingPager, error)
+}
+
+// LocationsAPI lists all locations
+type LocationsAPI interface {
+	All() (LocationPager, error)
+}
+
+// TournamentAPI is the endpoints of a single tournament
+type TournamentAPI interface {
+	Get() (Tournament, error)
+}
+
+// TournamentsAPI searches all tournaments
+type TournamentsAPI interface {
+	Search(query *TournamentQuery) (TournamentPager, error)
+}
+
+// ReplayAPI is the endpoints of a single replay
+type ReplayAPI interface {
+	Get() (Replay, error)
+}
+
+var (
+	_ API = (*Client)(nil)
+	_ API = (*MemoryAPI)(nil)
+)
@@ -0,0 +1,154 @@
+package clash
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency is how many requests a batch has in flight at once unless
+// the client says otherwise
+const DefaultBatchConcurrency = 8
+
+// BatchResult is what a batch fetched for one tag
+type BatchResult[T any] struct {
+	Tag   string // Normalised
+	Value T
+	Err   error
+}
+
+type PlayersService struct {
+	c    *Client
+	tags []string
+}
+
+// Players returns a batch of players. Use Player for a single one.
+func (c *Client) Players(tags ...string) PlayersAPI {
+	return &PlayersService{c, tags}
+}
+
+type ClanBatchService struct {
+	c    *Client
+	tags []string
+}
+
+// ClansByTag returns a batch of clans. Use Clan for a single one and Clans to search.
+func (c *Client) ClansByTag(tags ...string) ClanBatchAPI {
+	return &ClanBatchService{c, tags}
+}
+
+// SetBatchConcurrency caps how many requests a batch has in flight at once
+func (c *Client) SetBatchConcurrency(n int) {
+	c.batchConcurrency = n
+}
+
+// SetRateLimit spaces out every request sent through the client, batches included, to
+// at most perSecond a second. Zero removes the limit.
+func (c *Client) SetRateLimit(perSecond float64) {
+	if perSecond <= 0 {
+		c.limiter = nil
+		return
+	}
+	c.limiter = &rateLimiter{every: time.Duration(float64(time.Second) / perSecond)}
+}
+
+func (c *Client) concurrency() int {
+	if c.batchConcurrency > 0 {
+		return c.batchConcurrency
+	}
+	return DefaultBatchConcurrency
+}
+
+// getTag fetches the object at a path formatted with a tag
+func (c *Client) getTag(ctx context.Context, path, tag string, v interface{}) error {
+	req, err := c.newTagRequest("GET", path, tag, nil)
+	if err == nil {
+		_, err = c.Do(req.WithContext(ctx), v, path)
+	}
+	return err
+}
+
+// Get the full profile of every player in the batch. A tag given twice is fetched
+// once, and one failing doesn't stop the others.
+func (i *PlayersService) GetAll(ctx context.Context) []BatchResult[Player] {
+	return fetchAll(ctx, i.tags, i.c.concurrency(), func(ctx context.Context, tag string) (Player, error) {
+		var player Player
+		err := i.c.getTag(ctx, "/v1/players/%s", tag, &player)
+		return player, err
+	})
+}
+
+// Get every clan in the batch. A tag given twice is fetched once, and one failing
+// doesn't stop the others.
+func (i *ClanBatchService) GetAll(ctx context.Context) []BatchResult[Clan] {
+	return fetchAll(ctx, i.tags, i.c.concurrency(), func(ctx context.Context, tag string) (Clan, error) {
+		var clan Clan
+		err := i.c.getTag(ctx, "/v1/clans/%s", tag, &clan)
+		return clan, err
+	})
+}
+
+// fetchAll gets every distinct tag with at most concurrency gets running at once.
+// Results keep the order the tags were first given in.
+func fetchAll[T any](ctx context.Context, tags []string, concurrency int, get func(ctx context.Context, tag string) (T, error)) []BatchResult[T] {
+	var results []BatchResult[T]
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = NormaliseTag(tag)
+		if !seen[tag] {
+			seen[tag] = true
+			results = append(results, BatchResult[T]{Tag: tag})
+		}
+	}
+
+	slots := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(result *BatchResult[T]) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				result.Value, result.Err = get(ctx, result.Tag)
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+	return results
+}
+
+// rateLimiter spaces requests out evenly. A client shares one between all its requests.
+type rateLimiter struct {
+	every time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the next request may go out, or the context is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.every)
+	l.mu.Unlock()
+
+	delay := at.Sub(now)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
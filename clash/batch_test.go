@@ -0,0 +1,84 @@
+package clash_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayersService_GetAll(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, most, requests := 0, 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		requests++
+		most = max(most, inFlight)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		tag := strings.TrimPrefix(r.URL.Path, "/v1/players/")
+		if tag == "#2RG" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"reason":"notFound"}`))
+			return
+		}
+		w.Write([]byte(`{"tag":"` + tag + `"}`))
+	}))
+	defer server.Close()
+
+	nop := func(string, ...interface{}) {}
+	client := clash.NewClient("token", nop, nop)
+	client.BaseURL, _ = url.Parse(server.URL)
+	client.SetBatchConcurrency(2)
+
+	results := client.Players("#2PP", "2pp", "#2RG", "#9LL", "#8QU", "#2CC", "#404").GetAll(context.Background())
+	assert.Len(t, results, 6)
+	assert.Equal(t, 5, requests)
+	assert.LessOrEqual(t, most, 2)
+
+	assert.Equal(t, "#2PP", results[0].Tag)
+	assert.Nil(t, results[0].Err)
+	assert.Equal(t, "#2PP", results[0].Value.Tag)
+	assert.True(t, clash.IsNotFoundErr(results[1].Err))
+	assert.Nil(t, results[2].Err)
+	assert.True(t, clash.IsInvalidTagErr(results[5].Err))
+
+	// A cancelled batch fails every tag instead of waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, result := range client.Players("#2PP", "#9LL").GetAll(ctx) {
+		assert.Error(t, result.Err)
+	}
+}
+
+func TestClient_SetRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	nop := func(string, ...interface{}) {}
+	client := clash.NewClient("token", nop, nop)
+	client.BaseURL, _ = url.Parse(server.URL)
+	client.SetRateLimit(50)
+
+	start := time.Now()
+	results := client.ClansByTag("#2PP", "#9LL", "#8QU", "#2CC", "#2RG").GetAll(context.Background())
+	for _, result := range results {
+		assert.Nil(t, result.Err)
+	}
+	// Five requests 20ms apart take at least 80ms however many run at once
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
@@ -128,11 +128,11 @@ type ClanService struct {
 	tag string
 }
 
-func (c *Client) Clans() *ClansService {
+func (c *Client) Clans() ClansAPI {
 	return &ClansService{c}
 }
 
-func (c *Client) Clan(tag string) *ClanService {
+func (c *Client) Clan(tag string) ClanAPI {
 	return &ClanService{c, tag}
 }
 
@@ -140,8 +140,7 @@ func (c *Client) Clan(tag string) *ClanService {
 // Clan tags can be found using clan search operation.
 func (i *ClanService) Get() (Clan, error) {
 	path := "/v1/clans/%s"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var clan Clan
 
 	if err == nil {
@@ -154,8 +153,7 @@ func (i *ClanService) Get() (Clan, error) {
 // Retrieve information about clan's current clan war
 func (i *ClanService) CurrentWar() (CurrentWar, error) {
 	path := "/v1/clans/%s/currentriverrace"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var war CurrentWar
 
 	if err == nil {
@@ -168,8 +166,7 @@ func (i *ClanService) CurrentWar() (CurrentWar, error) {
 // Retrieve clan's clan war log
 func (i *ClanService) WarLog() (WarLogPager, error) {
 	path := "/v1/clans/%s/riverracelog"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var warLog WarLogPager
 
 	if err == nil {
@@ -182,8 +179,7 @@ func (i *ClanService) WarLog() (WarLogPager, error) {
 // List clan members
 func (i *ClanService) Members() (MemberPager, error) {
 	path := "/v1/clans/%s/members"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var members MemberPager
 
 	if err == nil {
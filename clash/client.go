@@ -31,6 +31,9 @@ type Client struct {
 	logError    func(format string, a ...interface{})
 	logInfo     func(format string, a ...interface{})
 	logTimeFunc logTimeFunc
+
+	limiter          *rateLimiter
+	batchConcurrency int
 }
 
 type PagedQuery struct {
@@ -120,7 +123,33 @@ func (c *Client) NewRequest(method, path string, body interface{}) (*http.Reques
 	return req, nil
 }
 
+// newTagRequest builds a request for a path with a tag in it. The tag is parsed first,
+// so a bad one fails without sending anything, and is escaped the same way in every path.
+func (c *Client) newTagRequest(method, path, tag string, body interface{}) (*http.Request, error) {
+	parsed, err := ParseTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	return c.newPathRequest(method, path, parsed.String(), body)
+}
+
+// newPathRequest builds a request for a path with one segment formatted into it,
+// escaping the segment so it can't change the rest of the path.
+func (c *Client) newPathRequest(method, path, segment string, body interface{}) (*http.Request, error) {
+	req, err := c.NewRequest(method, fmt.Sprintf(path, segment), body)
+	if err == nil {
+		req.URL.RawPath = fmt.Sprintf(path, url.PathEscape(segment))
+	}
+	return req, err
+}
+
 func (c *Client) Do(req *http.Request, v interface{}, label string) (*http.Response, error) {
+	if c.limiter != nil {
+		if err := c.limiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
 	start := time.Now()
 	c.logInfo("(go-clash) %s -> %s", req.Method, req.URL.String())
 
@@ -150,13 +179,16 @@ func (c *Client) Do(req *http.Request, v interface{}, label string) (*http.Respo
 			)
 		}
 
+		body := strings.TrimSpace(string(rawBody))
+
+		// Proxies and gateways answer with bodies that aren't the API's JSON; those are
+		// still API errors
 		errorResponse := &ErrorBody{}
-		err = json.Unmarshal(rawBody, errorResponse)
-		if err == nil {
-			err = &APIError{resp, errorResponse}
+		if json.Unmarshal(rawBody, errorResponse) != nil {
+			errorResponse = &ErrorBody{Reason: http.StatusText(resp.StatusCode), Message: body}
 		}
+		err = &APIError{resp, errorResponse}
 
-		body := strings.TrimSpace(string(rawBody))
 		if resp.StatusCode == http.StatusNotFound {
 			c.logInfo(
 				"(go-clash) Unexpected status code: %d -> %s: %s, body: -->%s<-- ",
@@ -188,10 +220,3 @@ func (c *Client) logTime(statusCode int, method string, path string, start time.
 		)
 	}
 }
-
-func NormaliseTag(tag string) string {
-	if len(tag) > 0 && tag[0] == '#' {
-		return tag
-	}
-	return "#" + tag
-}
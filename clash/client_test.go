@@ -1,6 +1,9 @@
 package clash_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -13,3 +16,30 @@ func TestTimeParsing(t *testing.T) {
 	tm, _ := time.Parse(clash.TimeLayout, "20180712T110230.000Z")
 	assert.Equal(t, int64(1531393350), tm.Unix())
 }
+
+// test that error responses are errors even when their body isn't the API's JSON.
+func TestClient_ErrorBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/players/#2PP" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"reason":"notFound"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>bad gateway</html>"))
+	}))
+	defer server.Close()
+
+	nop := func(string, ...interface{}) {}
+	client := clash.NewClient("token", nop, nop)
+	client.BaseURL, _ = url.Parse(server.URL)
+
+	_, err := client.Player("2PP").Get()
+	assert.True(t, clash.IsNotFoundErr(err))
+	assert.Equal(t, "notFound", err.(*clash.APIError).Body.Reason)
+
+	_, err = client.Player("9LL").Get()
+	assert.Error(t, err)
+	assert.False(t, clash.IsNotFoundErr(err))
+	assert.Equal(t, "<html>bad gateway</html>", err.(*clash.APIError).Body.Message)
+}
@@ -0,0 +1,114 @@
+package clash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FixtureTransport answers API requests from JSON files in a directory instead of the
+// network, so a Client can be used offline. A request path maps to a file by dropping
+// the version and the '#' of tags:
+//
+//	/v1/players/#ABC               -> Dir/players/ABC.json
+//	/v1/clans/#ABC/members         -> Dir/clans/ABC/members.json
+//	/v1/locations/global/rankings/players -> Dir/locations/global/rankings/players.json
+//	/v1/tournaments?name=cup       -> Dir/tournaments.json
+//
+// Lists are filtered by the name parameter and cut to the limit parameter like the API
+// does. A missing file is a 404.
+type FixtureTransport struct {
+	Dir string
+}
+
+func NewFixtureTransport(dir string) *FixtureTransport {
+	return &FixtureTransport{Dir: dir}
+}
+
+// SetTransport replaces how requests are sent, for example with a FixtureTransport
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c.httpClient.Transport = transport
+}
+
+func (t *FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return fixtureResponse(req, http.StatusMethodNotAllowed, ErrorBody{Reason: "notAllowed", Message: "fixtures are read-only"})
+	}
+
+	file := t.file(req.URL.Path)
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return fixtureResponse(req, http.StatusNotFound, ErrorBody{Reason: "notFound", Message: "no fixture " + file})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("fixture %s: %v", file, err)
+	}
+	if list, ok := body.(map[string]interface{}); ok {
+		if items, ok := list["items"].([]interface{}); ok {
+			list["items"] = filterFixtureItems(items, req)
+		}
+	}
+	return fixtureResponse(req, http.StatusOK, body)
+}
+
+// file returns the fixture file of a request path
+func (t *FixtureTransport) file(requestPath string) string {
+	// Cleaning the rooted path keeps ".." from leaving the directory
+	clean := strings.TrimPrefix(path.Clean("/"+requestPath), "/")
+	clean = strings.TrimPrefix(clean, "v1/")
+	clean = strings.Replace(clean, "#", "", -1)
+	return filepath.Join(t.Dir, filepath.FromSlash(clean)+".json")
+}
+
+// filterFixtureItems keeps the items whose name contains the name parameter, up to
+// the limit parameter
+func filterFixtureItems(items []interface{}, req *http.Request) []interface{} {
+	query := req.URL.Query()
+	name := strings.ToLower(query.Get("name"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	kept := []interface{}{}
+	for _, item := range items {
+		if limit > 0 && len(kept) == limit {
+			break
+		}
+		if fields, ok := item.(map[string]interface{}); ok && name != "" {
+			itemName, _ := fields["name"].(string)
+			if !strings.Contains(strings.ToLower(itemName), name) {
+				continue
+			}
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+func fixtureResponse(req *http.Request, status int, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
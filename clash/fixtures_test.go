@@ -0,0 +1,53 @@
+package clash_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFixture(t *testing.T, dir, name, body string) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.Nil(t, ioutil.WriteFile(path, []byte(body), 0644))
+}
+
+func TestFixtureTransport(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "fixtures")
+	writeFixture(t, root, "secret.json", `{"id": 1}`)
+	writeFixture(t, dir, "players/2PP.json", `{"tag": "#2PP", "name": "Fixture"}`)
+	writeFixture(t, dir, "tournaments.json", `{"items": [{"name": "Goblin Cup"}, {"name": "Royal Cup"}, {"name": "Goblin Open"}]}`)
+
+	nop := func(string, ...interface{}) {}
+	client := clash.NewClient("", nop, nop)
+	client.SetTransport(clash.NewFixtureTransport(dir))
+
+	player, err := client.Player("#2PP").Get()
+	assert.Nil(t, err)
+	assert.Equal(t, "Fixture", player.Name)
+
+	_, err = client.Player("#9LL").Get()
+	assert.True(t, clash.IsNotFoundErr(err))
+
+	// Bad tags fail before a request is made
+	_, err = client.Player("../../players/2PP").Get()
+	assert.True(t, clash.IsInvalidTagErr(err))
+
+	// Paths can't leave the fixture directory
+	_, err = client.Location("../../secret").Get()
+	assert.True(t, clash.IsNotFoundErr(err))
+
+	found, err := client.Tournaments().Search(&clash.TournamentQuery{Name: "goblin"})
+	assert.Nil(t, err)
+	assert.Len(t, found.Items, 2)
+
+	found, err = client.Tournaments().Search(&clash.TournamentQuery{Name: "cup", PagedQuery: clash.PagedQuery{Limit: 1}})
+	assert.Nil(t, err)
+	assert.Len(t, found.Items, 1)
+	assert.Equal(t, "Goblin Cup", found.Items[0].Name)
+}
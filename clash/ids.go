@@ -0,0 +1,68 @@
+package clash
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// GameID is how the game stores a tag: the number the tag stands for split into its
+// lowest 8 bits (High) and the rest (Low). Every valid tag fits.
+type GameID struct {
+	High int32 `json:"high"`
+	Low  int32 `json:"low"`
+}
+
+// GameID returns the tag's high/low pair
+func (t Tag) GameID() GameID {
+	id := t.ID()
+	return GameID{High: int32(id & 0xff), Low: int32(id >> 8)}
+}
+
+// Tag returns the tag of the pair, or a *TagError if the pair isn't one of a valid tag
+func (g GameID) Tag() (Tag, error) {
+	return TagFromID(g.Int64())
+}
+
+// Int64 packs the pair into one integer for use as a key. It is the number the tag
+// stands for, so keys sort the same as Tag.Compare.
+func (g GameID) Int64() int64 {
+	return int64(g.Low)<<8 | int64(g.High&0xff)
+}
+
+// GameIDFromInt64 unpacks a pair packed by GameID.Int64
+func GameIDFromInt64(key int64) GameID {
+	return GameID{High: int32(key & 0xff), Low: int32(key >> 8)}
+}
+
+func (g GameID) String() string {
+	return fmt.Sprintf("%d-%d", g.High, g.Low)
+}
+
+// Compare orders pairs the way their tags are ordered by Tag.Compare
+func (g GameID) Compare(other GameID) int {
+	if c := cmp.Compare(g.Low, other.Low); c != 0 {
+		return c
+	}
+	return cmp.Compare(g.High, other.High)
+}
+
+// Compare orders tags by the number they stand for, so "#2222" comes after "#999"
+func (t Tag) Compare(other Tag) int {
+	return cmp.Compare(t.ID(), other.ID())
+}
+
+// CompareTags orders tags as they come from the API or users. Valid tags are ordered
+// by Tag.Compare and come before anything else, which is ordered as strings.
+func CompareTags(a, b string) int {
+	tagA, errA := ParseTag(a)
+	tagB, errB := ParseTag(b)
+	switch {
+	case errA == nil && errB == nil:
+		return tagA.Compare(tagB)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return cmp.Compare(a, b)
+}
@@ -0,0 +1,39 @@
+package clash_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGameID(t *testing.T) {
+	// #2PP is 1*14*14 + 4*14 + 4 = 256
+	assert.Equal(t, clash.GameID{High: 0, Low: 1}, clash.Tag("#2PP").GameID())
+	assert.Equal(t, clash.GameID{High: 1, Low: 0}, clash.Tag("#2").GameID())
+
+	for _, tag := range []clash.Tag{"#2PP", "#9PLJLPQ8G", "#VVVVVVVVVV"} {
+		id := tag.GameID()
+		fromID, err := id.Tag()
+		assert.Nil(t, err)
+		assert.Equal(t, tag, fromID)
+		assert.Equal(t, id, clash.GameIDFromInt64(id.Int64()))
+		assert.Equal(t, tag.ID(), id.Int64())
+	}
+
+	_, err := clash.GameID{High: 1, Low: 0}.Tag()
+	assert.True(t, clash.IsInvalidTagErr(err))
+}
+
+func TestCompareTags(t *testing.T) {
+	tags := []string{"#2222", "not a tag", "#9PLJLPQ8G", "#999", "2pp"}
+	slices.SortFunc(tags, clash.CompareTags)
+	assert.Equal(t, []string{"2pp", "#999", "#2222", "#9PLJLPQ8G", "not a tag"}, tags)
+
+	a, b := clash.Tag("#2222").GameID(), clash.Tag("#9PLJLPQ8G").GameID()
+	assert.Equal(t, -1, a.Compare(b))
+	assert.Equal(t, 0, a.Compare(a))
+	// Keys order like the tags even when High alone would not: #VVV has the higher High
+	assert.Less(t, clash.Tag("#VVV").GameID().Int64(), clash.Tag("#2222").GameID().Int64())
+}
@@ -55,12 +55,12 @@ type LocationService struct {
 	id string
 }
 
-func (c *Client) Locations() *LocationsService {
+func (c *Client) Locations() LocationsAPI {
 	return &LocationsService{c}
 }
 
 // NB: Location ID is a string. This is because 'global' is a valid location ID.
-func (c *Client) Location(id string) *LocationService {
+func (c *Client) Location(id string) LocationAPI {
 	return &LocationService{c, id}
 }
 
@@ -81,7 +81,7 @@ func (i *LocationsService) All() (LocationPager, error) {
 // Get information about specific location
 func (i *LocationService) Get() (Location, error) {
 	path := "/v1/locations/%s"
-	req, err := i.c.NewRequest("GET", fmt.Sprintf(path, i.id), nil)
+	req, err := i.c.newPathRequest("GET", path, i.id, nil)
 
 	var location Location
 
@@ -95,7 +95,7 @@ func (i *LocationService) Get() (Location, error) {
 // Get clan rankings for a specific location
 func (i *LocationService) ClanRankings(query *PagedQuery) (LocationClanRankingPager, error) {
 	path := "/v1/locations/%s/rankings/clans"
-	req, err := i.c.NewRequest("GET", fmt.Sprintf(path, i.id), nil)
+	req, err := i.c.newPathRequest("GET", path, i.id, nil)
 
 	q := req.URL.Query()
 
@@ -125,7 +125,7 @@ func (i *LocationService) ClanRankings(query *PagedQuery) (LocationClanRankingPa
 // Get player rankings for a specific location
 func (i *LocationService) PlayerRankings(query *PagedQuery) (LocationPlayerRankingPager, error) {
 	path := "/v1/locations/%s/rankings/players"
-	req, err := i.c.NewRequest("GET", fmt.Sprintf(path, i.id), nil)
+	req, err := i.c.newPathRequest("GET", path, i.id, nil)
 
 	q := req.URL.Query()
 
@@ -155,7 +155,7 @@ func (i *LocationService) PlayerRankings(query *PagedQuery) (LocationPlayerRanki
 // Get clan war rankings for a specific location
 func (i *LocationService) ClanWarRankings(query *PagedQuery) (LocationClanRankingPager, error) {
 	path := "/v1/locations/%s/rankings/clanwars"
-	req, err := i.c.NewRequest("GET", fmt.Sprintf(path, i.id), nil)
+	req, err := i.c.newPathRequest("GET", path, i.id, nil)
 
 	q := req.URL.Query()
 
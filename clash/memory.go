@@ -0,0 +1,381 @@
+package clash
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MemoryData is what a MemoryAPI answers with. Clan members come from each clan's
+// MemberList. The maps are keyed by player or clan tag, or by location id for
+// rankings ("global" or the numeric id).
+type MemoryData struct {
+	Players         []Player
+	BattleLogs      map[string]Battles
+	UpcomingChests  map[string]UpcomingChests
+	Tokens          map[string]string // API token of each player, for VerifyToken
+	Clans           []Clan
+	CurrentWars     map[string]CurrentWar
+	WarLogs         map[string][]War
+	Locations       []Location
+	PlayerRankings  map[string][]PlayerRanking
+	ClanRankings    map[string][]ClanRanking
+	ClanWarRankings map[string][]ClanRanking
+	Tournaments     []Tournament
+	Replays         []Replay
+}
+
+// MemoryAPI is an API that answers from MemoryData instead of the network, for tests
+// and offline play. Anything it doesn't hold is a 404 APIError, like the live API.
+type MemoryAPI struct {
+	data        MemoryData
+	players     map[string]Player
+	clans       map[string]Clan
+	tournaments map[string]Tournament
+	replays     map[string]Replay
+}
+
+func NewMemoryAPI(data MemoryData) *MemoryAPI {
+	m := &MemoryAPI{
+		data:        data,
+		players:     map[string]Player{},
+		clans:       map[string]Clan{},
+		tournaments: map[string]Tournament{},
+		replays:     map[string]Replay{},
+	}
+	for _, p := range data.Players {
+		m.players[NormaliseTag(p.Tag)] = p
+	}
+	for _, c := range data.Clans {
+		m.clans[NormaliseTag(c.Tag)] = c
+	}
+	for _, t := range data.Tournaments {
+		m.tournaments[NormaliseTag(t.Tag)] = t
+	}
+	for _, r := range data.Replays {
+		m.replays[NormaliseTag(r.Tag)] = r
+	}
+	m.data.BattleLogs = normaliseKeys(data.BattleLogs)
+	m.data.UpcomingChests = normaliseKeys(data.UpcomingChests)
+	m.data.Tokens = normaliseKeys(data.Tokens)
+	m.data.CurrentWars = normaliseKeys(data.CurrentWars)
+	m.data.WarLogs = normaliseKeys(data.WarLogs)
+	return m
+}
+
+// normaliseKeys returns a copy of a map keyed by tag with every tag normalised
+func normaliseKeys[V any](byTag map[string]V) map[string]V {
+	normalised := make(map[string]V, len(byTag))
+	for tag, v := range byTag {
+		normalised[NormaliseTag(tag)] = v
+	}
+	return normalised
+}
+
+// limitItems cuts a list to a query's limit
+func limitItems[T any](items []T, query *PagedQuery) []T {
+	if query != nil && query.Limit > 0 && len(items) > query.Limit {
+		return items[:query.Limit]
+	}
+	return items
+}
+
+// memoryNotFound is the error the live API gives for something that doesn't exist
+func memoryNotFound(what string) error {
+	return &APIError{
+		Response: &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found"},
+		Body:     &ErrorBody{Reason: "notFound", Message: what + " not found"},
+	}
+}
+
+// memoryTag parses a tag the way the client does before a request, so a bad tag gives
+// the same *TagError instead of a 404
+func memoryTag(tag string) (string, error) {
+	parsed, err := ParseTag(tag)
+	return parsed.String(), err
+}
+
+func (m *MemoryAPI) Player(tag string) PlayerAPI {
+	parsed, err := memoryTag(tag)
+	return &memoryPlayer{m, parsed, err}
+}
+
+func (m *MemoryAPI) Clan(tag string) ClanAPI {
+	parsed, err := memoryTag(tag)
+	return &memoryClan{m, parsed, err}
+}
+
+func (m *MemoryAPI) Players(tags ...string) PlayersAPI {
+	return &memoryPlayers{m, tags}
+}
+
+func (m *MemoryAPI) Clans() ClansAPI {
+	return &memoryClans{m}
+}
+
+func (m *MemoryAPI) ClansByTag(tags ...string) ClanBatchAPI {
+	return &memoryClanBatch{m, tags}
+}
+
+func (m *MemoryAPI) Location(id string) LocationAPI {
+	return &memoryLocation{m, id}
+}
+
+func (m *MemoryAPI) Locations() LocationsAPI {
+	return &memoryLocations{m}
+}
+
+func (m *MemoryAPI) Tournament(tag string) TournamentAPI {
+	parsed, err := memoryTag(tag)
+	return &memoryTournament{m, parsed, err}
+}
+
+func (m *MemoryAPI) Tournaments() TournamentsAPI {
+	return &memoryTournaments{m}
+}
+
+func (m *MemoryAPI) Replay(tag string) ReplayAPI {
+	parsed, err := memoryTag(tag)
+	return &memoryReplay{m, parsed, err}
+}
+
+type memoryPlayer struct {
+	m   *MemoryAPI
+	tag string
+	err error // From parsing the tag
+}
+
+func (i *memoryPlayer) Get() (Player, error) {
+	if i.err != nil {
+		return Player{}, i.err
+	}
+	player, ok := i.m.players[i.tag]
+	if !ok {
+		return Player{}, memoryNotFound("player " + i.tag)
+	}
+	return player, nil
+}
+
+func (i *memoryPlayer) UpcomingChests() (UpcomingChests, error) {
+	if _, err := i.Get(); err != nil {
+		return UpcomingChests{}, err
+	}
+	return i.m.data.UpcomingChests[i.tag], nil
+}
+
+func (i *memoryPlayer) BattleLog() (Battles, error) {
+	if _, err := i.Get(); err != nil {
+		return nil, err
+	}
+	return i.m.data.BattleLogs[i.tag], nil
+}
+
+func (i *memoryPlayer) VerifyToken(token string) (VerificationResult, error) {
+	if _, err := i.Get(); err != nil {
+		return VerificationResult{}, err
+	}
+	result := VerificationResult{Tag: i.tag, Token: token, Status: "invalid"}
+	if expected, ok := i.m.data.Tokens[i.tag]; ok && expected == token {
+		result.Status = "ok"
+	}
+	return result, nil
+}
+
+type memoryClan struct {
+	m   *MemoryAPI
+	tag string
+	err error // From parsing the tag
+}
+
+func (i *memoryClan) Get() (Clan, error) {
+	if i.err != nil {
+		return Clan{}, i.err
+	}
+	clan, ok := i.m.clans[i.tag]
+	if !ok {
+		return Clan{}, memoryNotFound("clan " + i.tag)
+	}
+	return clan, nil
+}
+
+func (i *memoryClan) Members() (MemberPager, error) {
+	clan, err := i.Get()
+	return MemberPager{Items: clan.MemberList}, err
+}
+
+func (i *memoryClan) CurrentWar() (CurrentWar, error) {
+	if _, err := i.Get(); err != nil {
+		return CurrentWar{}, err
+	}
+	war, ok := i.m.data.CurrentWars[i.tag]
+	if !ok {
+		return CurrentWar{State: "notInWar"}, nil
+	}
+	return war, nil
+}
+
+func (i *memoryClan) WarLog() (WarLogPager, error) {
+	if _, err := i.Get(); err != nil {
+		return WarLogPager{}, err
+	}
+	return WarLogPager{Items: i.m.data.WarLogs[i.tag]}, nil
+}
+
+type memoryPlayers struct {
+	m    *MemoryAPI
+	tags []string
+}
+
+func (i *memoryPlayers) GetAll(ctx context.Context) []BatchResult[Player] {
+	return fetchAll(ctx, i.tags, DefaultBatchConcurrency, func(ctx context.Context, tag string) (Player, error) {
+		return i.m.Player(tag).Get()
+	})
+}
+
+type memoryClanBatch struct {
+	m    *MemoryAPI
+	tags []string
+}
+
+func (i *memoryClanBatch) GetAll(ctx context.Context) []BatchResult[Clan] {
+	return fetchAll(ctx, i.tags, DefaultBatchConcurrency, func(ctx context.Context, tag string) (Clan, error) {
+		return i.m.Clan(tag).Get()
+	})
+}
+
+type memoryClans struct {
+	m *MemoryAPI
+}
+
+// Search filters the clans the same way the live API reads a ClanQuery
+func (i *memoryClans) Search(query *ClanQuery) (ClanPager, error) {
+	if query == nil {
+		query = &ClanQuery{}
+	}
+	name := strings.ToLower(query.Name)
+	var clans []Clan
+
+	for _, clan := range i.m.data.Clans {
+		switch {
+		case query.LocationId > 0 && clan.Location.ID != query.LocationId:
+		case query.MinScore > 0 && clan.ClanScore < query.MinScore:
+		case query.MinMembers >= 2 && clan.Members < query.MinMembers:
+		case query.MaxMembers >= 1 && clan.Members > query.MaxMembers:
+		case len(name) >= 3 && !strings.Contains(strings.ToLower(clan.Name), name):
+		default:
+			clans = append(clans, clan)
+		}
+	}
+
+	return ClanPager{Items: limitItems(clans, &query.PagedQuery)}, nil
+}
+
+type memoryLocation struct {
+	m  *MemoryAPI
+	id string
+}
+
+func (i *memoryLocation) Get() (Location, error) {
+	for _, location := range i.m.data.Locations {
+		if strconv.Itoa(location.ID) == i.id {
+			return location, nil
+		}
+	}
+	return Location{}, memoryNotFound("location " + i.id)
+}
+
+// known reports whether the location is held or has rankings, as "global" is not a
+// location of its own
+func (i *memoryLocation) known() error {
+	_, players := i.m.data.PlayerRankings[i.id]
+	_, clans := i.m.data.ClanRankings[i.id]
+	_, wars := i.m.data.ClanWarRankings[i.id]
+	if players || clans || wars {
+		return nil
+	}
+	_, err := i.Get()
+	return err
+}
+
+func (i *memoryLocation) ClanRankings(query *PagedQuery) (LocationClanRankingPager, error) {
+	err := i.known()
+	return LocationClanRankingPager{Items: limitItems(i.m.data.ClanRankings[i.id], query)}, err
+}
+
+func (i *memoryLocation) PlayerRankings(query *PagedQuery) (LocationPlayerRankingPager, error) {
+	err := i.known()
+	return LocationPlayerRankingPager{Items: limitItems(i.m.data.PlayerRankings[i.id], query)}, err
+}
+
+func (i *memoryLocation) ClanWarRankings(query *PagedQuery) (LocationClanRankingPager, error) {
+	err := i.known()
+	return LocationClanRankingPager{Items: limitItems(i.m.data.ClanWarRankings[i.id], query)}, err
+}
+
+type memoryLocations struct {
+	m *MemoryAPI
+}
+
+func (i *memoryLocations) All() (LocationPager, error) {
+	return LocationPager{Items: i.m.data.Locations}, nil
+}
+
+type memoryTournament struct {
+	m   *MemoryAPI
+	tag string
+	err error // From parsing the tag
+}
+
+func (i *memoryTournament) Get() (Tournament, error) {
+	if i.err != nil {
+		return Tournament{}, i.err
+	}
+	tournament, ok := i.m.tournaments[i.tag]
+	if !ok {
+		return Tournament{}, memoryNotFound("tournament " + i.tag)
+	}
+	return tournament, nil
+}
+
+type memoryTournaments struct {
+	m *MemoryAPI
+}
+
+// Search finds tournaments whose name contains the query's name
+func (i *memoryTournaments) Search(query *TournamentQuery) (TournamentPager, error) {
+	if query == nil || query.Name == "" {
+		return TournamentPager{}, &APIError{
+			Response: &http.Response{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"},
+			Body:     &ErrorBody{Reason: "badRequest", Message: "name is required"},
+		}
+	}
+
+	name := strings.ToLower(query.Name)
+	var tournaments []Tournament
+
+	for _, tournament := range i.m.data.Tournaments {
+		if strings.Contains(strings.ToLower(tournament.Name), name) {
+			tournaments = append(tournaments, tournament)
+		}
+	}
+
+	return TournamentPager{Items: limitItems(tournaments, &query.PagedQuery)}, nil
+}
+
+type memoryReplay struct {
+	m   *MemoryAPI
+	tag string
+	err error // From parsing the tag
+}
+
+func (i *memoryReplay) Get() (Replay, error) {
+	if i.err != nil {
+		return Replay{}, i.err
+	}
+	replay, ok := i.m.replays[i.tag]
+	if !ok {
+		return Replay{}, memoryNotFound("replay " + i.tag)
+	}
+	return replay, nil
+}
@@ -0,0 +1,80 @@
+package clash_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAPI(t *testing.T) {
+	var api clash.API = clash.NewMemoryAPI(clash.MemoryData{
+		Players: []clash.Player{{Tag: "#2PP", Name: "Memory"}},
+		Tokens:  map[string]string{"2PP": "secret"},
+		Clans: []clash.Clan{
+			{Tag: "#8QU", Name: "Goblin Gang", Members: 2, MemberList: []clash.ClanMember{{Tag: "#2PP"}, {Tag: "#9LL"}}},
+			{Tag: "#9RG", Name: "Royal Goblins", Members: 40},
+		},
+		PlayerRankings: map[string][]clash.PlayerRanking{"global": {{Tag: "#2PP"}, {Tag: "#9LL"}}},
+	})
+
+	player, err := api.Player("2PP").Get()
+	assert.Nil(t, err)
+	assert.Equal(t, "Memory", player.Name)
+
+	_, err = api.Player("#9LL").Get()
+	assert.True(t, clash.IsNotFoundErr(err))
+
+	verified, err := api.Player("#2PP").VerifyToken("secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", verified.Status)
+
+	members, err := api.Clan("#8QU").Members()
+	assert.Nil(t, err)
+	assert.Len(t, members.Items, 2)
+
+	found, err := api.Clans().Search(&clash.ClanQuery{Name: "goblin", MinMembers: 10})
+	assert.Nil(t, err)
+	assert.Len(t, found.Items, 1)
+	assert.Equal(t, "Royal Goblins", found.Items[0].Name)
+
+	rankings, err := api.Location("global").PlayerRankings(&clash.PagedQuery{Limit: 1})
+	assert.Nil(t, err)
+	assert.Len(t, rankings.Items, 1)
+
+	_, err = api.Location("57000000").PlayerRankings(&clash.PagedQuery{})
+	assert.True(t, clash.IsNotFoundErr(err))
+
+	batch := api.ClansByTag("#8QU", "9rg", "#2CC")
+	results := batch.GetAll(context.Background())
+	assert.Len(t, results, 3)
+	assert.Equal(t, "Goblin Gang", results[0].Value.Name)
+	assert.Equal(t, "Royal Goblins", results[1].Value.Name)
+	assert.True(t, clash.IsNotFoundErr(results[2].Err))
+
+	all, err := api.Clans().Search(nil)
+	assert.Nil(t, err)
+	assert.Len(t, all.Items, 2)
+}
+
+func TestMemoryAPI_InvalidTag(t *testing.T) {
+	api := clash.NewMemoryAPI(clash.MemoryData{})
+	for _, tt := range []struct {
+		name string
+		get  func() error
+	}{
+		{"player", func() error { _, err := api.Player("#ABC").Get(); return err }},
+		{"battle log", func() error { _, err := api.Player("#ABC").BattleLog(); return err }},
+		{"clan", func() error { _, err := api.Clan("").Get(); return err }},
+		{"clan members", func() error { _, err := api.Clan("").Members(); return err }},
+		{"tournament", func() error { _, err := api.Tournament("#2P!").Get(); return err }},
+		{"replay", func() error { _, err := api.Replay("#2").Get(); return err }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.get()
+			assert.True(t, clash.IsInvalidTagErr(err), "%v", err)
+			assert.False(t, clash.IsNotFoundErr(err))
+		})
+	}
+}
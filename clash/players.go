@@ -2,7 +2,6 @@ package clash
 
 import (
 	"errors"
-	"fmt"
 	"time"
 )
 
@@ -207,15 +206,14 @@ type PlayerService struct {
 	tag string
 }
 
-func (c *Client) Player(tag string) *PlayerService {
+func (c *Client) Player(tag string) PlayerAPI {
 	return &PlayerService{c, tag}
 }
 
 // Get list of reward chests that the player will receive next in the game.
 func (i *PlayerService) UpcomingChests() (UpcomingChests, error) {
 	path := "/v1/players/%s/upcomingchests"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var chests UpcomingChests
 
 	if err == nil {
@@ -228,8 +226,7 @@ func (i *PlayerService) UpcomingChests() (UpcomingChests, error) {
 // Get list of recent battle results for a player.
 func (i *PlayerService) BattleLog() (Battles, error) {
 	path := "/v1/players/%s/battlelog"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var list Battles
 
 	if err == nil {
@@ -243,8 +240,7 @@ func (i *PlayerService) BattleLog() (Battles, error) {
 // can be found either in game or by from clan member lists.
 func (i *PlayerService) Get() (Player, error) {
 	path := "/v1/players/%s"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var player Player
 
 	if err == nil {
@@ -260,8 +256,7 @@ func (i *PlayerService) Get() (Player, error) {
 // can only be retrieved inside the game from settings view.
 func (i *PlayerService) VerifyToken(token string) (VerificationResult, error) {
 	path := "/v1/players/%s/verifytoken"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("POST", url, map[string]string{"token": token})
+	req, err := i.c.newTagRequest("POST", path, i.tag, map[string]string{"token": token})
 	var result VerificationResult
 
 	if err == nil {
@@ -0,0 +1,132 @@
+package clash
+
+import (
+	"context"
+	"sync"
+)
+
+// PlayerRef is a player as other endpoints mention them: a clan member, tournament
+// member, ranked player or war participant. It holds what those have in common and
+// can be resolved into the full Player.
+type PlayerRef struct {
+	Tag      string     `json:"tag"`
+	Name     string     `json:"name"`
+	Trophies int        `json:"trophies"`
+	Score    int        `json:"score"` // Tournament score, or fame in a river race
+	Clan     PlayerClan `json:"clan"`
+	Deck     []Card     `json:"deck,omitempty"` // A deck already known, like a war deck
+}
+
+// Ref returns the clan member as a PlayerRef. Members don't carry their clan, so
+// Clan.MemberRefs fills it in.
+func (m *ClanMember) Ref() PlayerRef {
+	return PlayerRef{Tag: m.Tag, Name: m.Name, Trophies: m.Trophies}
+}
+
+// MemberRefs returns the members of the clan as PlayerRefs
+func (c *Clan) MemberRefs() []PlayerRef {
+	refs := make([]PlayerRef, len(c.MemberList))
+	for i := range c.MemberList {
+		refs[i] = c.MemberList[i].Ref()
+		refs[i].Clan = PlayerClan{Tag: c.Tag, Name: c.Name, BadgeID: c.BadgeId}
+	}
+	return refs
+}
+
+func (m *TournamentMember) Ref() PlayerRef {
+	return PlayerRef{Tag: m.Tag, Name: m.Name, Score: m.Score, Clan: m.Clan}
+}
+
+func (r *PlayerRanking) Ref() PlayerRef {
+	return PlayerRef{Tag: r.Tag, Name: r.Name, Trophies: r.Trophies, Clan: r.Clan}
+}
+
+// Ref returns the participant as a PlayerRef. Participants don't carry their clan, so
+// WarClanDetails.ParticipantRefs fills it in.
+func (p *WarParticipant) Ref() PlayerRef {
+	return PlayerRef{Tag: p.Tag, Name: p.Name, Score: p.Fame}
+}
+
+// ParticipantRefs returns the participants of the clan as PlayerRefs
+func (d *WarClanDetails) ParticipantRefs() []PlayerRef {
+	refs := make([]PlayerRef, len(d.Participants))
+	for i := range d.Participants {
+		refs[i] = d.Participants[i].Ref()
+		refs[i].Clan = PlayerClan{Tag: d.Tag, Name: d.Name, BadgeID: d.BadgeId}
+	}
+	return refs
+}
+
+func (p *Player) Ref() PlayerRef {
+	return PlayerRef{Tag: p.Tag, Name: p.Name, Trophies: p.Trophies, Clan: p.Clan, Deck: p.CurrentDeck}
+}
+
+// Resolver enriches PlayerRefs into full Players. Each player is fetched from the API
+// once and kept; failed lookups are tried again next time.
+type Resolver struct {
+	API API
+
+	mu      sync.Mutex
+	players map[string]Player
+}
+
+func NewResolver(api API) *Resolver {
+	return &Resolver{API: api, players: map[string]Player{}}
+}
+
+// Resolve returns the full player a ref points at. A deck the ref already knows
+// replaces the player's current deck.
+func (r *Resolver) Resolve(ref PlayerRef) (Player, error) {
+	player, err := r.Player(ref.Tag)
+	if err == nil && len(ref.Deck) > 0 {
+		player.CurrentDeck = ref.Deck
+	}
+	return player, err
+}
+
+// Player returns the player with the given tag, from the cache when it was fetched before
+func (r *Resolver) Player(tag string) (Player, error) {
+	tag = NormaliseTag(tag)
+
+	r.mu.Lock()
+	player, ok := r.players[tag]
+	r.mu.Unlock()
+	if ok {
+		return player, nil
+	}
+
+	player, err := r.API.Player(tag).Get()
+	if err != nil {
+		return player, err
+	}
+
+	r.mu.Lock()
+	r.players[tag] = player
+	r.mu.Unlock()
+	return player, nil
+}
+
+// Prefetch fetches every player not fetched yet in one batch, so resolving them later
+// doesn't wait on the API. Players that fail are left for Resolve to report.
+func (r *Resolver) Prefetch(ctx context.Context, tags ...string) {
+	var missing []string
+	r.mu.Lock()
+	for _, tag := range tags {
+		if _, ok := r.players[NormaliseTag(tag)]; !ok {
+			missing = append(missing, tag)
+		}
+	}
+	r.mu.Unlock()
+	if len(missing) == 0 {
+		return
+	}
+
+	results := r.API.Players(missing...).GetAll(ctx)
+	r.mu.Lock()
+	for _, result := range results {
+		if result.Err == nil {
+			r.players[result.Tag] = result.Value
+		}
+	}
+	r.mu.Unlock()
+}
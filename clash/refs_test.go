@@ -0,0 +1,61 @@
+package clash_test
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingAPI counts the player lookups made through it
+type countingAPI struct {
+	clash.API
+	lookups int
+}
+
+func (c *countingAPI) Player(tag string) clash.PlayerAPI {
+	c.lookups++
+	return c.API.Player(tag)
+}
+
+func TestPlayerRef_Converters(t *testing.T) {
+	clan := clash.Clan{Tag: "#8QU", Name: "Goblin Gang", MemberList: []clash.ClanMember{{Tag: "#2PP", Name: "Member", Trophies: 4000}}}
+	refs := clan.MemberRefs()
+	assert.Equal(t, clash.PlayerRef{Tag: "#2PP", Name: "Member", Trophies: 4000, Clan: clash.PlayerClan{Tag: "#8QU", Name: "Goblin Gang"}}, refs[0])
+
+	member := clash.TournamentMember{Tag: "#9LL", Name: "Entrant", Score: 12, Clan: clash.PlayerClan{Tag: "#8QU"}}
+	assert.Equal(t, clash.PlayerRef{Tag: "#9LL", Name: "Entrant", Score: 12, Clan: clash.PlayerClan{Tag: "#8QU"}}, member.Ref())
+
+	ranking := clash.PlayerRanking{Tag: "#9LL", Name: "Ranked", Trophies: 7000}
+	assert.Equal(t, 7000, ranking.Ref().Trophies)
+
+	war := clash.WarClanDetails{Tag: "#8QU", Name: "Goblin Gang", Participants: []clash.WarParticipant{{Tag: "#2PP", Fame: 900}}}
+	assert.Equal(t, 900, war.ParticipantRefs()[0].Score)
+	assert.Equal(t, "#8QU", war.ParticipantRefs()[0].Clan.Tag)
+}
+
+func TestResolver(t *testing.T) {
+	deck := []clash.Card{{Name: "Knight"}}
+	api := &countingAPI{API: clash.NewMemoryAPI(clash.MemoryData{
+		Players: []clash.Player{{Tag: "#2PP", Name: "Resolved", CurrentDeck: deck}},
+	})}
+	players := clash.NewResolver(api)
+
+	player, err := players.Resolve(clash.PlayerRef{Tag: "#2PP"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Resolved", player.Name)
+
+	// A deck the ref knows wins, and the player comes from the cache
+	warDeck := []clash.Card{{Name: "Archers"}}
+	player, err = players.Resolve(clash.PlayerRef{Tag: "2PP", Deck: warDeck})
+	assert.Nil(t, err)
+	assert.Equal(t, warDeck, player.CurrentDeck)
+	assert.Equal(t, 1, api.lookups)
+
+	// Failures aren't cached
+	_, err = players.Resolve(clash.PlayerRef{Tag: "#9LL"})
+	assert.True(t, clash.IsNotFoundErr(err))
+	_, err = players.Resolve(clash.PlayerRef{Tag: "#9LL"})
+	assert.True(t, clash.IsNotFoundErr(err))
+	assert.Equal(t, 3, api.lookups)
+}
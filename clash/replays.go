@@ -1,7 +1,5 @@
 package clash
 
-import "fmt"
-
 type ReplayVersion struct {
 	Major   int `json:"major"`
 	Build   int `json:"build"`
@@ -23,15 +21,14 @@ type ReplayService struct {
 	tag string
 }
 
-func (c *Client) Replay(tag string) *ReplayService {
+func (c *Client) Replay(tag string) ReplayAPI {
 	return &ReplayService{c, tag}
 }
 
 // Get information about a single replay by a replay tag.
 func (i *ReplayService) Get() (Replay, error) {
 	path := "/v1/replays/%s"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var replay Replay
 
 	if err == nil {
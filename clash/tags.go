@@ -0,0 +1,97 @@
+package clash
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagChars are the characters tags are written in. A tag is a number in base 14 with
+// these as its digits, most significant first.
+const TagChars = "0289PYLQGRJCUV"
+
+const (
+	MinTagLength = 3
+	MaxTagLength = 10 // The longest tag the game's high/low IDs can hold
+)
+
+// Tag is a player, clan, tournament or replay tag in its canonical form: '#' followed
+// by TagChars. Parse user input with ParseTag.
+type Tag string
+
+// TagError is why a tag couldn't be parsed. It is returned before any request is sent.
+type TagError struct {
+	Tag    string
+	Reason string
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("invalid tag %q: %s", e.Tag, e.Reason)
+}
+
+func IsInvalidTagErr(err error) bool {
+	_, ok := err.(*TagError)
+	return ok
+}
+
+// ParseTag reads a tag as people type it: with or without '#' (or its escaped form
+// %23), in any case and with the letter O for zero.
+func ParseTag(input string) (Tag, error) {
+	s := strings.TrimSpace(input)
+	if strings.HasPrefix(s, "%23") {
+		s = s[len("%23"):]
+	}
+	s = strings.ToUpper(strings.TrimPrefix(s, "#"))
+	s = strings.Replace(s, "O", "0", -1)
+
+	if len(s) < MinTagLength || len(s) > MaxTagLength {
+		return "", &TagError{input, fmt.Sprintf("must be %d to %d characters long", MinTagLength, MaxTagLength)}
+	}
+	for _, r := range s {
+		if !strings.ContainsRune(TagChars, r) {
+			return "", &TagError{input, fmt.Sprintf("%q is not one of %s", r, TagChars)}
+		}
+	}
+	if s[0] == TagChars[0] {
+		// Tags are numbers, which aren't written with leading zeros
+		return "", &TagError{input, "cannot start with 0"}
+	}
+	return Tag("#" + s), nil
+}
+
+// TagFromID returns the tag of a numeric ID. IDs whose tags ParseTag would reject, too
+// short, too long or not positive, fail with a *TagError.
+func TagFromID(id int64) (Tag, error) {
+	var digits []byte
+	for n := id; n > 0; n /= int64(len(TagChars)) {
+		digits = append([]byte{TagChars[n%int64(len(TagChars))]}, digits...)
+	}
+	if len(digits) < MinTagLength || len(digits) > MaxTagLength {
+		return "", &TagError{fmt.Sprint(id), fmt.Sprintf("is not the ID of a %d to %d character tag", MinTagLength, MaxTagLength)}
+	}
+	return Tag("#" + string(digits)), nil
+}
+
+// ID returns the number the tag stands for
+func (t Tag) ID() int64 {
+	var id int64
+	for _, r := range strings.TrimPrefix(string(t), "#") {
+		id = id*int64(len(TagChars)) + int64(strings.IndexRune(TagChars, r))
+	}
+	return id
+}
+
+func (t Tag) String() string {
+	return string(t)
+}
+
+// NormaliseTag returns a tag in its canonical form. Strings that aren't tags are only
+// given a '#' prefix.
+func NormaliseTag(tag string) string {
+	if parsed, err := ParseTag(tag); err == nil {
+		return parsed.String()
+	}
+	if len(tag) > 0 && tag[0] == '#' {
+		return tag
+	}
+	return "#" + tag
+}
@@ -0,0 +1,48 @@
+package clash_test
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTag(t *testing.T) {
+	for input, want := range map[string]clash.Tag{
+		"#9PLJLPQ8G":    "#9PLJLPQ8G",
+		"9pljlpq8g":     "#9PLJLPQ8G",
+		" %239PLJLPQ8G": "#9PLJLPQ8G",
+		"#2OO":          "#200",
+	} {
+		tag, err := clash.ParseTag(input)
+		assert.Nil(t, err, input)
+		assert.Equal(t, want, tag, input)
+	}
+
+	for _, input := range []string{"", "#2P", "#9PLJLPQ8GPQ", "#9PLJ-PQ8G", "#ABC", "#0PP", "../2PP"} {
+		_, err := clash.ParseTag(input)
+		assert.True(t, clash.IsInvalidTagErr(err), input)
+	}
+}
+
+func TestTag_ID(t *testing.T) {
+	assert.Equal(t, int64(1), clash.Tag("#2").ID())
+	assert.Equal(t, int64(14), clash.Tag("#20").ID())
+
+	for _, tag := range []clash.Tag{"#2PP", "#9PLJLPQ8G", "#VVVVVVVVVV"} {
+		fromID, err := clash.TagFromID(tag.ID())
+		assert.Nil(t, err)
+		assert.Equal(t, tag, fromID)
+	}
+
+	// Every tag TagFromID returns parses, so IDs of tags too short or long fail
+	for _, id := range []int64{-1, 0, clash.Tag("#99").ID(), clash.Tag("#VVVVVVVVVV").ID() + 1} {
+		_, err := clash.TagFromID(id)
+		assert.True(t, clash.IsInvalidTagErr(err), "id %d", id)
+	}
+}
+
+func TestNormaliseTag(t *testing.T) {
+	assert.Equal(t, "#9PLJLPQ8G", clash.NormaliseTag("9pljlpq8g"))
+	assert.Equal(t, "#111", clash.NormaliseTag("111"))
+}
@@ -61,19 +61,18 @@ type TournamentsService struct {
 	c *Client
 }
 
-func (c *Client) Tournaments() *TournamentsService {
+func (c *Client) Tournaments() TournamentsAPI {
 	return &TournamentsService{c}
 }
 
-func (c *Client) Tournament(tag string) *TournamentService {
+func (c *Client) Tournament(tag string) TournamentAPI {
 	return &TournamentService{c, tag}
 }
 
 // Get information about a single tournament by a tournament tag.
 func (i *TournamentService) Get() (Tournament, error) {
 	path := "/v1/tournaments/%s"
-	url := fmt.Sprintf(path, NormaliseTag(i.tag))
-	req, err := i.c.NewRequest("GET", url, nil)
+	req, err := i.c.newTagRequest("GET", path, i.tag, nil)
 	var tournament Tournament
 
 	if err == nil {
@@ -0,0 +1,517 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	// Connects to client.go, players.go, clans.go, locations.go and tournaments.go: The
+	// commands are thin wrappers around the API services
+	"github.com/fiskie/go-clash/clash"
+)
+
+// Exit codes of the goclash commands
+const (
+	exitOK       = 0
+	exitError    = 1 // The API call or the match failed
+	exitUsage    = 2 // The command line is wrong
+	exitNotFound = 3 // The API has no such player, clan or tournament
+)
+
+// tokenEnv is the environment variable the API token is read from
+const tokenEnv = "CLASH_TOKEN"
+
+// command is a goclash subcommand. run is given the arguments after the command's
+// name and returns the exit code.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) int
+}
+
+// commands are run with "goclash <command>"; without one the interactive game starts
+var commands = []command{
+	{"player", "player get [tag]", "Show a player's profile, by default the profile's player", playerCommand},
+	{"clan", "clan members <tag>", "List the members of a clan", clanCommand},
+	{"rankings", "rankings players|clans [--location global] [--limit 20]", "Show the top of a location's rankings", rankingsCommand},
+	{"tournament", "tournament search <name> [--limit 20]", "Search tournaments by name", tournamentCommand},
+	{"play", "play [--tag <tag>] [--mode normal|ranked] [--seed 42] [--auto] [--test]", "Play a match without the menus", playCommand},
+	{"replay", "replay [--view] <file>", "Verify or watch a saved replay", replayCommand},
+	{"config", "config show | get | set | unset | use | remove", "Show or edit the config file and its profiles", configCommand},
+}
+
+// runCommand runs a subcommand by name and returns its exit code
+func runCommand(name string, args []string) int {
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(args)
+		}
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+	fmt.Fprintf(os.Stderr, "goclash: unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+// printUsage lists the commands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: goclash [command]")
+	fmt.Fprintln(w, "\nWithout a command the game starts with its menus. Commands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.usage, c.summary)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nThe API token and defaults are taken from the flags, the environment ($%s, $%s, ...)\n", tokenEnv, profileEnv)
+	fmt.Fprintf(w, "or the profile in %s.\n", configPath())
+	fmt.Fprintln(w, "Run \"goclash <command> -h\" for the flags of a command.")
+}
+
+// newFlagSet creates the flags of a command, printing usage as its usage line
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: goclash %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses a command's flags, which may come before, between or after its
+// arguments, and returns the arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// usageError returns the exit code for a command line that couldn't be run. Parse
+// errors have printed the usage already; -h is not an error.
+func usageError(fs *flag.FlagSet, err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err == nil {
+		fs.Usage()
+	}
+	return exitUsage
+}
+
+// outputFormat is the value of --format
+type outputFormat string
+
+func (o *outputFormat) String() string {
+	return string(*o)
+}
+
+func (o *outputFormat) Set(value string) error {
+	if value != "table" && value != "json" {
+		return fmt.Errorf("must be table or json")
+	}
+	*o = outputFormat(value)
+	return nil
+}
+
+// apiFlags are the flags shared by the commands that call the API
+type apiFlags struct {
+	profile string
+	token   string
+	format  outputFormat
+	verbose bool
+	test    bool
+}
+
+// newAPIFlags adds --profile, --token, --format, --verbose and --test to a command
+func newAPIFlags(fs *flag.FlagSet) *apiFlags {
+	f := &apiFlags{format: "table"}
+	fs.StringVar(&f.profile, "profile", "", "config profile (default $"+profileEnv+" or the current one)")
+	fs.StringVar(&f.token, "token", "", "API token (default $"+tokenEnv+" or the profile's)")
+	fs.Var(&f.format, "format", "output format: table or json")
+	fs.BoolVar(&f.verbose, "verbose", false, "log API requests to stderr")
+	fs.BoolVar(&f.test, "test", false, "answer from the fixture directory instead of the API")
+	return f
+}
+
+// logger returns a logger that writes to stderr with --verbose and discards everything
+// otherwise, so it never mixes with the output
+func (f *apiFlags) logger() *Logger {
+	if !f.verbose {
+		return &Logger{}
+	}
+	return &Logger{
+		infoLog:  log.New(os.Stderr, "INFO: ", log.LstdFlags),
+		errorLog: log.New(os.Stderr, "ERROR: ", log.LstdFlags),
+	}
+}
+
+// settings returns the profile's settings with --token applied. A broken config file
+// is reported and returns the exit code for it.
+func (f *apiFlags) settings() (Settings, int) {
+	settings, err := loadSettings(f.profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+		return settings, exitUsage
+	}
+	if f.token != "" {
+		settings.Token = f.token
+	}
+	return settings, exitOK
+}
+
+// client creates an API client from the settings, or a fixture client with --test. A
+// missing token is reported and returns the exit code for it.
+func (f *apiFlags) client(settings Settings) (clash.API, int) {
+	if f.test {
+		return settings.newFixtureClient(f.logger()), exitOK
+	}
+	if settings.Token == "" {
+		fmt.Fprintf(os.Stderr, "goclash: no API token: pass --token, set $%s or run \"goclash config set token <token>\"\n", tokenEnv)
+		return nil, exitUsage
+	}
+	return settings.newClient(f.logger()), exitOK
+}
+
+// apiClient is client with the profile's settings
+func (f *apiFlags) apiClient() (clash.API, Settings, int) {
+	settings, code := f.settings()
+	if code != exitOK {
+		return nil, settings, code
+	}
+	client, code := f.client(settings)
+	return client, settings, code
+}
+
+// write prints v as JSON, or as the table drawn by table
+func (f *apiFlags) write(v interface{}, table func(w io.Writer)) int {
+	if f.format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v); err != nil {
+			fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	table(w)
+	w.Flush()
+	return exitOK
+}
+
+// apiFailure reports a failed API call and returns the exit code for it
+func apiFailure(what string, err error) int {
+	fmt.Fprintf(os.Stderr, "goclash: %s: %v\n", what, err)
+	if clash.IsNotFoundErr(err) {
+		return exitNotFound
+	}
+	if clash.IsInvalidTagErr(err) {
+		return exitUsage
+	}
+	return exitError
+}
+
+// cardList joins the names and levels of cards
+func cardList(cards []clash.Card) string {
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = fmt.Sprintf("%s (%d)", card.Name, card.Level)
+	}
+	return strings.Join(names, ", ")
+}
+
+// playerCommand runs "goclash player get <tag>"
+func playerCommand(args []string) int {
+	fs := newFlagSet("player", "player get [tag] [flags]")
+	api := newAPIFlags(fs)
+	args, err := parseFlags(fs, args)
+	if err != nil || len(args) < 1 || len(args) > 2 || args[0] != "get" {
+		return usageError(fs, err)
+	}
+	client, settings, code := api.apiClient()
+	if client == nil {
+		return code
+	}
+	tag := settings.Tag
+	if len(args) == 2 {
+		tag = args[1]
+	}
+	if tag == "" {
+		return usageError(fs, nil)
+	}
+
+	// Connects to players.go: Fetches the player via client.Player(tag).Get()
+	player, err := client.Player(tag).Get()
+	if err != nil {
+		return apiFailure("fetching player "+clash.NormaliseTag(tag), err)
+	}
+	return api.write(player, func(w io.Writer) {
+		fmt.Fprintf(w, "Tag\t%s\n", player.Tag)
+		fmt.Fprintf(w, "Name\t%s\n", player.Name)
+		fmt.Fprintf(w, "Level\t%d\n", player.ExpLevel)
+		fmt.Fprintf(w, "Trophies\t%d (best %d)\n", player.Trophies, player.BestTrophies)
+		fmt.Fprintf(w, "Arena\t%s\n", player.Arena.Name)
+		if player.Clan.Tag != "" {
+			fmt.Fprintf(w, "Clan\t%s (%s)\n", player.Clan.Name, player.Clan.Tag)
+		}
+		fmt.Fprintf(w, "Battles\t%d (%d wins, %d losses, %d three-crown wins)\n",
+			player.BattleCount, player.Wins, player.Losses, player.ThreeCrownWins)
+		fmt.Fprintf(w, "Deck\t%s\n", cardList(player.CurrentDeck))
+	})
+}
+
+// clanCommand runs "goclash clan members <tag>"
+func clanCommand(args []string) int {
+	fs := newFlagSet("clan", "clan members <tag> [flags]")
+	api := newAPIFlags(fs)
+	args, err := parseFlags(fs, args)
+	if err != nil || len(args) != 2 || args[0] != "members" {
+		return usageError(fs, err)
+	}
+	client, _, code := api.apiClient()
+	if client == nil {
+		return code
+	}
+
+	// Connects to clans.go: Fetches clan members via client.Clan(tag).Members()
+	members, err := client.Clan(args[1]).Members()
+	if err != nil {
+		return apiFailure("fetching members of "+clash.NormaliseTag(args[1]), err)
+	}
+	return api.write(members.Items, func(w io.Writer) {
+		fmt.Fprintln(w, "RANK\tTAG\tNAME\tROLE\tLEVEL\tTROPHIES\tDONATIONS")
+		for _, m := range members.Items {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%d\t%d\n", m.ClanRank, m.Tag, m.Name, m.Role, m.ExpLevel, m.Trophies, m.Donations)
+		}
+	})
+}
+
+// rankingsCommand runs "goclash rankings players|clans"
+func rankingsCommand(args []string) int {
+	fs := newFlagSet("rankings", "rankings players|clans [flags]")
+	api := newAPIFlags(fs)
+	location := fs.String("location", "", "location ID, or global (default the profile's or global)")
+	limit := fs.Int("limit", 20, "number of entries")
+	args, err := parseFlags(fs, args)
+	if err != nil || len(args) != 1 || (args[0] != "players" && args[0] != "clans") {
+		return usageError(fs, err)
+	}
+	client, settings, code := api.apiClient()
+	if client == nil {
+		return code
+	}
+	if *location == "" {
+		*location = settings.location()
+	}
+
+	query := &clash.PagedQuery{Limit: *limit}
+	if args[0] == "clans" {
+		// Connects to locations.go: Fetches rankings via client.Location(id).ClanRankings()
+		rankings, err := client.Location(*location).ClanRankings(query)
+		if err != nil {
+			return apiFailure("fetching clan rankings of "+*location, err)
+		}
+		return api.write(rankings.Items, func(w io.Writer) {
+			fmt.Fprintln(w, "RANK\tTAG\tNAME\tMEMBERS\tSCORE")
+			for _, r := range rankings.Items {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\n", r.Rank, r.Tag, r.Name, r.Members, r.ClanScore)
+			}
+		})
+	}
+	// Connects to locations.go: Fetches rankings via client.Location(id).PlayerRankings()
+	rankings, err := client.Location(*location).PlayerRankings(query)
+	if err != nil {
+		return apiFailure("fetching player rankings of "+*location, err)
+	}
+	return api.write(rankings.Items, func(w io.Writer) {
+		fmt.Fprintln(w, "RANK\tTAG\tNAME\tLEVEL\tTROPHIES\tCLAN")
+		for _, r := range rankings.Items {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%s\n", r.Rank, r.Tag, r.Name, r.ExpLevel, r.Trophies, r.Clan.Name)
+		}
+	})
+}
+
+// tournamentCommand runs "goclash tournament search <name>"
+func tournamentCommand(args []string) int {
+	fs := newFlagSet("tournament", "tournament search <name> [flags]")
+	api := newAPIFlags(fs)
+	limit := fs.Int("limit", 20, "number of tournaments")
+	args, err := parseFlags(fs, args)
+	if err != nil || len(args) < 2 || args[0] != "search" {
+		return usageError(fs, err)
+	}
+	client, _, code := api.apiClient()
+	if client == nil {
+		return code
+	}
+
+	name := strings.Join(args[1:], " ")
+	// Connects to tournaments.go: Searches tournaments via client.Tournaments().Search()
+	query := &clash.TournamentQuery{Name: name, PagedQuery: clash.PagedQuery{Limit: *limit}}
+	tournaments, err := client.Tournaments().Search(query)
+	if err != nil {
+		return apiFailure(fmt.Sprintf("searching tournaments named %q", name), err)
+	}
+	return api.write(tournaments.Items, func(w io.Writer) {
+		fmt.Fprintln(w, "TAG\tNAME\tSTATUS\tTYPE\tPLAYERS")
+		for _, t := range tournaments.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/%d\n", t.Tag, t.Name, t.Status, t.Type, t.Capacity, t.MaxCapacity)
+		}
+	})
+}
+
+// playCommand runs "goclash play": one match against an opponent picked like the menu's
+// Normal or Ranked Mode. With --auto the AI plays the player's side too and nothing is
+// drawn, so a seed always gives the same match. Without it the arena view takes stdout,
+// so --format json needs --auto.
+func playCommand(args []string) int {
+	fs := newFlagSet("play", "play [--tag <tag>] [flags]")
+	api := newAPIFlags(fs)
+	tag := fs.String("tag", "", "your player tag (default the profile's)")
+	mode := fs.String("mode", "normal", "normal (a clan member) or ranked (the ladder)")
+	seed := fs.Int64("seed", 0, "match seed (default random)")
+	strategy := fs.String("strategy", "", "opponent strategy: random, elixir or counter (default the profile's difficulty)")
+	ruleset := fs.String("ruleset", "", "ruleset to play with (default the profile's or "+defaultRuleset+")")
+	location := fs.String("location", "", "location of the ladder's rankings (default the profile's or global)")
+	auto := fs.Bool("auto", false, "let the AI play your side and skip the arena view")
+	args, err := parseFlags(fs, args)
+	if err != nil || len(args) != 0 || (*mode != "normal" && *mode != "ranked") {
+		return usageError(fs, err)
+	}
+	if api.format == "json" && !*auto {
+		fmt.Fprintln(os.Stderr, "goclash: --format json needs --auto, the arena view is drawn on stdout")
+		return exitUsage
+	}
+	settings, code := api.settings()
+	if code != exitOK {
+		return code
+	}
+	if *tag == "" {
+		*tag = settings.Tag
+	}
+	if *tag == "" {
+		return usageError(fs, nil)
+	}
+	rules := settings.rules()
+	if *ruleset != "" {
+		if rules, err = lookupRuleset(*ruleset); err != nil {
+			fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+			return exitUsage
+		}
+	}
+	if *strategy == "" {
+		*strategy = settings.strategy()
+	}
+	ai, err := newOpponent(*strategy, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+		return exitUsage
+	}
+	if *location == "" {
+		*location = settings.location()
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	logger := api.logger()
+
+	client, code := api.client(settings)
+	if client == nil {
+		return code
+	}
+	// Connects to refs.go: The player and their opponent are fetched through one resolver
+	players := clash.NewResolver(client)
+	player, err := players.Player(*tag)
+	if err != nil {
+		return apiFailure("fetching player "+clash.NormaliseTag(*tag), err)
+	}
+
+	profiles, err := loadProfiles(profilePath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+		return exitError
+	}
+	profile := profiles.Profile(player)
+	player.Trophies = profile.Trophies
+
+	// The opponent is picked with the match seed too
+	rng := rand.New(rand.NewSource(*seed))
+	opponent := defaultOpponent
+	var ladder *Ladder
+	var ladderOpponent *LadderPlayer
+	switch {
+	case *mode == "ranked":
+		ladder = buildLadder(client, settings.localPlayers(logger), player.Tag, *location, *seed, logger)
+		if match, _, err := ladder.Match(player.Trophies); err == nil {
+			ladderOpponent = match
+			opponent = match.Ref()
+		}
+	case player.Clan.Tag != "":
+		// Connects to clans.go: Fetches clan members via client.Clan(player.Clan.Tag).Members()
+		members, err := client.Clan(player.Clan.Tag).Members()
+		if err != nil {
+			return apiFailure("fetching members of "+player.Clan.Tag, err)
+		}
+		if member, ok := clanOpponent(members.Items, player, rng); ok {
+			opponent = member
+		}
+	}
+
+	var replay ReplayData
+	if *auto {
+		enemyDeck, _ := opponentDeck(players, player, opponent, logger)
+		replay = autoMatch(player, opponent, enemyDeck, rules, *seed, ai)
+	} else {
+		replay = playGame(newLineReader(os.Stdin), players, player, opponent, rules, *seed, ai, logger)
+	}
+
+	if ladderOpponent != nil && len(replay.Result.Opponent) > 0 {
+		ladder.Settle(ladderOpponent, replay.Result.Opponent[0].TrophyChange)
+	}
+
+	path, err := saveReplay(&replay)
+	if err != nil {
+		logger.Error("Error saving replay: %v", err)
+	}
+	match := profile.Record(&replay.Result, *mode, path)
+	if err := profiles.Save(); err != nil {
+		logger.Error("Error saving profile: %v", err)
+	}
+	return api.write(match, func(w io.Writer) {
+		fmt.Fprintf(w, "Seed\t%d\n", replay.Seed)
+		fmt.Fprintf(w, "Opponent\t%s (%d trophies)\n", match.Opponent, opponent.Trophies)
+		fmt.Fprintf(w, "Result\t%s %d-%d, %s\n", match.Result, match.Crowns, match.OpponentCrowns, replay.Final.Reason)
+		fmt.Fprintf(w, "Trophies\t%d (%+d)\n", match.Trophies, match.TrophyChange)
+		if match.Replay != "" {
+			fmt.Fprintf(w, "Replay\t%s\n", match.Replay)
+		}
+	})
+}
+
+// autoMatch plays a match with the elixir-aware strategy on the player's side and
+// returns its replay
+func autoMatch(player clash.Player, opponent clash.PlayerRef, enemyDeck []clash.Card, rules Ruleset, seed int64, ai Opponent) ReplayData {
+	state := newGameState(rules, seed, "Player", opponent.Name, player.CurrentDeck, enemyDeck)
+	replay := newReplay(&state, player, opponent, enemyDeck)
+	replay.finish(&state, simulateMatch(&state, [2]Opponent{&ElixirOpponent{}, ai}), player)
+	return replay
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoMatch(t *testing.T) {
+	pool := cardPool(nil, 9)
+	player := clash.Player{Tag: "#2PP", Name: "A", Trophies: 4000, CurrentDeck: pool[:handSize*2]}
+	opponent := clash.PlayerRef{Tag: "#9LL", Name: "B", Trophies: 4100}
+	enemyDeck := pool[handSize*2 : handSize*4]
+
+	replay := autoMatch(player, opponent, enemyDeck, rulesets["ladder"], 7, &CounterOpponent{})
+	assert.Equal(t, int64(7), replay.Seed)
+	assert.Equal(t, player.CurrentDeck, replay.Player.Deck)
+	assert.Equal(t, enemyDeck, replay.Opponent.Deck)
+	assert.Equal(t, "A", replay.Result.Team[0].Name)
+	assert.Equal(t, 4100, replay.Result.Opponent[0].StartingTrophies)
+	assert.Equal(t, replay.Final.PlayerCrowns, replay.Result.Team[0].Crowns)
+
+	// The replay of an auto-played match plays out the same again
+	_, diffs, err := verifyReplay(&replay)
+	assert.Nil(t, err)
+	assert.Empty(t, diffs)
+	again := autoMatch(player, opponent, enemyDeck, rulesets["ladder"], 7, &CounterOpponent{})
+	assert.Equal(t, replay.Plays, again.Plays)
+	assert.Equal(t, replay.Final, again.Final)
+}
+
+func TestReplayCommand(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		code int
+	}{
+		{nil, exitUsage},
+		{[]string{"a.json", "b.json"}, exitUsage},
+		{[]string{"--speed", "2", "a.json"}, exitUsage},
+		{[]string{"-h"}, exitOK},
+		{[]string{filepath.Join(t.TempDir(), "missing.json"), "--view"}, exitError},
+	} {
+		assert.Equal(t, tt.code, replayCommand(tt.args), "%v", tt.args)
+	}
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	// Connects to client.go: Settings configure clash.Client's BaseURL and timeout, and
+	// hand it out as a clash.API
+	"github.com/fiskie/go-clash/clash"
+)
+
+// profileEnv picks the profile when --profile isn't given
+const profileEnv = "CLASH_PROFILE"
+
+// defaultProfileName is the profile used when none is chosen
+const defaultProfileName = "default"
+
+// defaultFixtures is the fixture directory test mode reads when the profile has none
+const defaultFixtures = "fixtures"
+
+// localPlayersFile holds the local players in the fixture directory. Their tags aren't
+// real, so the API is never asked about them.
+const localPlayersFile = "player.json"
+
+// Settings is one named profile of the config file. Empty fields fall back to the
+// built-in defaults.
+type Settings struct {
+	Token      string `json:"token,omitempty"`
+	Tag        string `json:"tag,omitempty"`        // Default player tag
+	Location   string `json:"location,omitempty"`   // Default location ID for rankings and the ladder
+	BaseURL    string `json:"baseURL,omitempty"`    // Replaces the API's address, for proxies and fakes
+	Timeout    string `json:"timeout,omitempty"`    // Request timeout such as "10s"
+	Ruleset    string `json:"ruleset,omitempty"`    // Ruleset matches are played with
+	Difficulty string `json:"difficulty,omitempty"` // AI difficulty: easy, normal or hard
+	Fixtures   string `json:"fixtures,omitempty"`   // Directory test mode serves the API from
+}
+
+// settingKey is a setting as named by "goclash config" and the environment variable
+// that overrides it
+type settingKey struct {
+	name  string
+	env   string
+	field func(s *Settings) *string
+	check func(value string) error
+}
+
+// settingKeys are the settings a profile holds
+var settingKeys = []settingKey{
+	{"token", tokenEnv, func(s *Settings) *string { return &s.Token }, nil},
+	{"tag", "CLASH_TAG", func(s *Settings) *string { return &s.Tag }, checkTag},
+	{"location", "CLASH_LOCATION", func(s *Settings) *string { return &s.Location }, nil},
+	{"base-url", "CLASH_BASE_URL", func(s *Settings) *string { return &s.BaseURL }, checkBaseURL},
+	{"timeout", "CLASH_TIMEOUT", func(s *Settings) *string { return &s.Timeout }, checkTimeout},
+	{"ruleset", "CLASH_RULESET", func(s *Settings) *string { return &s.Ruleset }, func(value string) error {
+		_, err := lookupRuleset(value)
+		return err
+	}},
+	{"difficulty", "CLASH_DIFFICULTY", func(s *Settings) *string { return &s.Difficulty }, func(value string) error {
+		_, err := difficultyStrategy(value)
+		return err
+	}},
+	{"fixtures", "CLASH_FIXTURES", func(s *Settings) *string { return &s.Fixtures }, nil},
+}
+
+// lookupSettingKey returns a setting by name
+func lookupSettingKey(name string) (settingKey, error) {
+	names := make([]string, len(settingKeys))
+	for i, key := range settingKeys {
+		if key.name == name {
+			return key, nil
+		}
+		names[i] = key.name
+	}
+	return settingKey{}, fmt.Errorf("unknown setting %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// checkTag accepts player tags, see clash.ParseTag
+func checkTag(value string) error {
+	_, err := clash.ParseTag(value)
+	return err
+}
+
+// checkBaseURL accepts absolute http and https URLs
+func checkBaseURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base URL %q must be an http or https URL", value)
+	}
+	return nil
+}
+
+// checkTimeout accepts positive durations such as "10s"
+func checkTimeout(value string) error {
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		return fmt.Errorf("timeout %q must be a duration such as 10s", value)
+	}
+	return nil
+}
+
+// Config is the config file: named profiles and the one used when none is chosen
+type Config struct {
+	path     string
+	Current  string               `json:"profile,omitempty"`
+	Profiles map[string]*Settings `json:"profiles"`
+}
+
+// configPath returns the config file, under $XDG_CONFIG_HOME/goclash on Linux
+func configPath() string {
+	return filepath.Join(goclashDir(), "config.json")
+}
+
+// loadConfig reads the config file. A missing file is an empty config.
+func loadConfig(path string) (*Config, error) {
+	config := &Config{path: path, Profiles: map[string]*Settings{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	if config.Profiles == nil {
+		config.Profiles = map[string]*Settings{}
+	}
+	return config, nil
+}
+
+// Save writes the config file. It holds the token, so only the user can read it.
+func (c *Config) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// profileName returns the profile to use: the one asked for, else $CLASH_PROFILE, else
+// the config's current profile, else the default one
+func (c *Config) profileName(name string) string {
+	for _, candidate := range []string{name, os.Getenv(profileEnv), c.Current} {
+		if candidate != "" {
+			return candidate
+		}
+	}
+	return defaultProfileName
+}
+
+// Settings returns the settings of a profile with the environment variables applied.
+// A profile that was asked for by name has to exist.
+func (c *Config) Settings(name string) (Settings, error) {
+	name = c.profileName(name)
+	var settings Settings
+	if profile, exists := c.Profiles[name]; exists {
+		settings = *profile
+	} else if name != defaultProfileName {
+		return settings, fmt.Errorf("no profile %q in %s", name, c.path)
+	}
+	for _, key := range settingKeys {
+		if value := os.Getenv(key.env); value != "" {
+			*key.field(&settings) = value
+		}
+	}
+	for _, key := range settingKeys {
+		value := *key.field(&settings)
+		if value == "" || key.check == nil {
+			continue
+		}
+		if err := key.check(value); err != nil {
+			if os.Getenv(key.env) != "" {
+				return settings, fmt.Errorf("$%s: %v", key.env, err)
+			}
+			return settings, fmt.Errorf("profile %q: %v", name, err)
+		}
+	}
+	return settings, nil
+}
+
+// loadSettings reads the settings of a profile from the config file
+func loadSettings(profile string) (Settings, error) {
+	config, err := loadConfig(configPath())
+	if err != nil {
+		return Settings{}, err
+	}
+	return config.Settings(profile)
+}
+
+// rules returns the profile's ruleset, or the default one
+func (s Settings) rules() Ruleset {
+	rules, err := lookupRuleset(s.Ruleset)
+	if err != nil {
+		rules, _ = lookupRuleset(defaultRuleset)
+	}
+	return rules
+}
+
+// strategy returns the opponent strategy of the profile's difficulty
+func (s Settings) strategy() string {
+	strategy, err := difficultyStrategy(s.Difficulty)
+	if err != nil {
+		strategy, _ = difficultyStrategy(defaultDifficulty)
+	}
+	return strategy
+}
+
+// location returns the profile's location, or global
+func (s Settings) location() string {
+	if s.Location == "" {
+		return "global"
+	}
+	return s.Location
+}
+
+// fixtures returns the profile's fixture directory, or "fixtures" in the working directory
+func (s Settings) fixtures() string {
+	if s.Fixtures == "" {
+		return defaultFixtures
+	}
+	return s.Fixtures
+}
+
+// localPlayers reads the local players from the fixture directory. Without the file
+// there are none.
+func (s Settings) localPlayers(logger *Logger) []clash.Player {
+	players, err := loadLocalPlayers(filepath.Join(s.fixtures(), localPlayersFile))
+	if err != nil && !os.IsNotExist(err) {
+		logger.Error("Error reading local players: %v", err)
+	}
+	return players
+}
+
+// newFixtureClient creates a client that answers from the fixture directory instead of
+// the API, for test mode
+func (s Settings) newFixtureClient(logger *Logger) clash.API {
+	client := clash.NewClient("", logger.Error, logger.Info)
+	// Connects to fixtures.go: Requests are read from the fixture files
+	client.SetTransport(clash.NewFixtureTransport(s.fixtures()))
+	logLatency(client, logger)
+	return client
+}
+
+// newClient creates an API client with the profile's token, base URL and timeout
+func (s Settings) newClient(logger *Logger) clash.API {
+	// Connects to client.go: Initializes clash.Client with NewClient
+	client := clash.NewClient(s.Token, logger.Error, logger.Info)
+	if s.BaseURL != "" {
+		client.BaseURL, _ = url.Parse(s.BaseURL)
+	}
+	if timeout, err := time.ParseDuration(s.Timeout); err == nil {
+		// Connects to client.go: Sets the request timeout
+		client.SetTimeout(timeout)
+	}
+	logLatency(client, logger)
+	return client
+}
+
+// logLatency logs how long each of the client's requests took
+func logLatency(client *clash.Client, logger *Logger) {
+	// Connects to client.go: Sets API latency logging
+	client.SetLogLatencyFunc(func(statusCode, method, host, path string, elapsed time.Duration) {
+		logger.Info("Latency %s %s -> %s (%s): %v", method, host, path, statusCode, elapsed)
+	})
+}
+
+// maskToken hides all but the start of a token
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:8] + "..."
+}
+
+// configCommand runs "goclash config": it shows and edits the config file
+func configCommand(args []string) int {
+	const usage = "config [--profile name] show | path | get <key> | set <key> <value> | unset <key> | use <profile> | remove <profile>"
+	fs := newFlagSet("config", usage)
+	profile := fs.String("profile", "", "profile to read or change (default the current one)")
+	args, err := parseFlags(fs, args)
+	if err != nil || len(args) == 0 {
+		return usageError(fs, err)
+	}
+	config, err := loadConfig(configPath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+		return exitError
+	}
+	name := config.profileName(*profile)
+
+	action, args := args[0], args[1:]
+	switch {
+	case action == "path" && len(args) == 0:
+		fmt.Println(config.path)
+		return exitOK
+	case action == "show" && len(args) == 0:
+		showConfig(config, name)
+		return exitOK
+	case action == "get" && len(args) == 1:
+		key, err := lookupSettingKey(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+			return exitUsage
+		}
+		settings, err := config.Settings(*profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+			return exitError
+		}
+		fmt.Println(*key.field(&settings))
+		return exitOK
+	case (action == "set" && len(args) == 2) || (action == "unset" && len(args) == 1):
+		key, err := lookupSettingKey(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+			return exitUsage
+		}
+		value := ""
+		if action == "set" {
+			value = args[1]
+			if key.check != nil {
+				if err := key.check(value); err != nil {
+					fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+					return exitUsage
+				}
+			}
+		}
+		settings, exists := config.Profiles[name]
+		if !exists {
+			settings = &Settings{}
+			config.Profiles[name] = settings
+		}
+		*key.field(settings) = value
+	case action == "use" && len(args) == 1:
+		if _, exists := config.Profiles[args[0]]; !exists {
+			config.Profiles[args[0]] = &Settings{}
+		}
+		config.Current = args[0]
+	case action == "remove" && len(args) == 1:
+		if _, exists := config.Profiles[args[0]]; !exists {
+			fmt.Fprintf(os.Stderr, "goclash: no profile %q\n", args[0])
+			return exitUsage
+		}
+		delete(config.Profiles, args[0])
+		if config.Current == args[0] {
+			config.Current = ""
+		}
+	default:
+		return usageError(fs, nil)
+	}
+	if err := config.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// showConfig prints every profile, marking the one in use, and the environment
+// variables that override it
+func showConfig(config *Config, current string) {
+	fmt.Printf("Config file: %s\n", config.path)
+	names := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		fmt.Println("No profiles yet. Add one with \"goclash config set <key> <value>\".")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		marker := ""
+		if name == current {
+			marker = " (in use)"
+		}
+		fmt.Fprintf(w, "\n[%s]%s\n", name, marker)
+		for _, key := range settingKeys {
+			value := *key.field(config.Profiles[name])
+			if key.name == "token" {
+				value = maskToken(value)
+			}
+			if value != "" {
+				fmt.Fprintf(w, "  %s\t%s\n", key.name, value)
+			}
+		}
+	}
+	var overrides []string
+	for _, key := range settingKeys {
+		if os.Getenv(key.env) != "" {
+			overrides = append(overrides, fmt.Sprintf("%s ($%s)", key.name, key.env))
+		}
+	}
+	if len(overrides) > 0 {
+		fmt.Fprintf(w, "\nOverridden by the environment: %s\n", strings.Join(overrides, ", "))
+	}
+	w.Flush()
+}
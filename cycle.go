@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	// Connects to players.go: The cycle is built from clash.Card decks
+	"github.com/fiskie/go-clash/clash"
+)
+
+// handSize is the number of cards a player can choose from
+const handSize = 4
+
+// CardCycle is a shuffled deck split into the hand a player can play from and the queue
+// of cards coming up next. Played cards go to the back of the queue.
+type CardCycle struct {
+	Hand  []clash.Card
+	Queue []clash.Card
+}
+
+// newCardCycle shuffles a deck and deals the opening hand
+func newCardCycle(deck []clash.Card, rng *rand.Rand) *CardCycle {
+	shuffled := make([]clash.Card, len(deck))
+	copy(shuffled, deck)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return dealCardCycle(shuffled)
+}
+
+// Next returns the card that replaces the next card played from the hand
+func (c *CardCycle) Next() (clash.Card, bool) {
+	if len(c.Queue) == 0 {
+		return clash.Card{}, false
+	}
+	return c.Queue[0], true
+}
+
+// Play takes the card in a hand slot (0-based), puts it at the back of the queue and
+// refills the slot with the next card
+func (c *CardCycle) Play(slot int) (clash.Card, error) {
+	if slot < 0 || slot >= len(c.Hand) {
+		return clash.Card{}, fmt.Errorf("no card in slot %d", slot+1)
+	}
+	played := c.Hand[slot]
+	c.Queue = append(c.Queue, played)
+	c.Hand[slot] = c.Queue[0]
+	c.Queue = c.Queue[1:]
+	return played, nil
+}
+
+// dealCardCycle deals a deck in the given order without shuffling it
+func dealCardCycle(order []clash.Card) *CardCycle {
+	dealt := make([]clash.Card, len(order))
+	copy(dealt, order)
+	n := min(handSize, len(dealt))
+	return &CardCycle{
+		Hand:  dealt[:n:n],
+		Queue: dealt[n:],
+	}
+}
+
+// Order returns the hand followed by the queue, the order the cycle would deal again
+func (c *CardCycle) Order() []clash.Card {
+	order := make([]clash.Card, 0, len(c.Hand)+len(c.Queue))
+	order = append(order, c.Hand...)
+	return append(order, c.Queue...)
+}
+
+// Slot returns the hand slot (0-based) holding a card, or -1
+func (c *CardCycle) Slot(name string) int {
+	for i, card := range c.Hand {
+		if card.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// CardPlay records a card played during a match
+type CardPlay struct {
+	Side Side          `json:"side"`
+	Card clash.Card    `json:"card"`
+	Lane Lane          `json:"lane"`
+	Tile *Tile         `json:"tile,omitempty"` // In the playing side's coordinates
+	At   time.Duration `json:"at"`
+}
+
+// playCard makes a move for a side: it checks where the card goes, pays the card's
+// elixir cost, cycles the card, deploys it and records the play. It returns the replay line.
+func playCard(state *GameState, side Side, move Move) (string, error) {
+	cycle := state.cycle(side)
+	if move.Slot < 0 || move.Slot >= len(cycle.Hand) {
+		return "", fmt.Errorf("no card in slot %d", move.Slot+1)
+	}
+	card := cycle.Hand[move.Slot]
+	stats := lookupCardStats(card.Name)
+	if move.Tile != nil {
+		if err := checkPlacement(state, side, stats, *move.Tile); err != nil {
+			return "", err
+		}
+		move.Lane = move.Tile.lane()
+	}
+	cost := float64(stats.ElixirCost)
+	if cost > state.elixir(side) {
+		return "", fmt.Errorf("not enough elixir for %s: need %.0f, have %.1f", card.Name, cost, state.elixir(side))
+	}
+
+	cycle.Play(move.Slot)
+	state.addElixir(side, -cost)
+	state.Plays = append(state.Plays, CardPlay{Side: side, Card: card, Lane: move.Lane, Tile: move.Tile, At: state.Elapsed})
+	return deployCard(state, side, card, move.Lane, move.Tile), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDeck returns a deck of eight made up cards named A to H
+func testDeck() []clash.Card {
+	var deck []clash.Card
+	for _, name := range []string{"A", "B", "C", "D", "E", "F", "G", "H"} {
+		deck = append(deck, clash.Card{Name: name, Level: 1})
+	}
+	return deck
+}
+
+func TestNewCardCycle(t *testing.T) {
+	cycle := newCardCycle(testDeck(), rand.New(rand.NewSource(1)))
+	assert.Len(t, cycle.Hand, handSize)
+	assert.Len(t, cycle.Queue, 4)
+	assert.ElementsMatch(t, testDeck(), append(append([]clash.Card(nil), cycle.Hand...), cycle.Queue...))
+
+	// The same seed shuffles the same way
+	assert.Equal(t, cycle, newCardCycle(testDeck(), rand.New(rand.NewSource(1))))
+
+	small := newCardCycle(testDeck()[:2], rand.New(rand.NewSource(1)))
+	assert.Len(t, small.Hand, 2)
+	assert.Empty(t, small.Queue)
+	_, ok := small.Next()
+	assert.False(t, ok)
+}
+
+func TestCardCycle_Play(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		slots  []int // Played in order
+		played string
+		hand   string
+		queue  string
+		err    bool
+	}{
+		{"refills from the queue", []int{1}, "B", "AECD", "FGHB", false},
+		{"played cards come back last", []int{0, 0, 0, 0, 0}, "H", "ABCD", "EFGH", false},
+		{"slot out of range", []int{4}, "", "ABCD", "EFGH", true},
+		{"negative slot", []int{-1}, "", "ABCD", "EFGH", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			deck := testDeck()
+			cycle := &CardCycle{Hand: deck[:4:4], Queue: deck[4:]}
+			var played clash.Card
+			var err error
+			for _, slot := range tt.slots {
+				played, err = cycle.Play(slot)
+			}
+			assert.Equal(t, tt.err, err != nil)
+			assert.Equal(t, tt.played, played.Name)
+			assert.Equal(t, tt.hand, names(cycle.Hand))
+			assert.Equal(t, tt.queue, names(cycle.Queue))
+		})
+	}
+}
+
+// names joins the names of cards named with a single letter
+func names(cards []clash.Card) string {
+	s := ""
+	for _, card := range cards {
+		s += card.Name
+	}
+	return s
+}
+
+func TestDealCardCycle(t *testing.T) {
+	cycle := dealCardCycle(testDeck())
+	assert.Equal(t, "ABCD", names(cycle.Hand))
+	assert.Equal(t, "EFGH", names(cycle.Queue))
+	assert.Equal(t, 2, cycle.Slot("C"))
+	assert.Equal(t, -1, cycle.Slot("E"))
+
+	// Order deals the same cycle again
+	cycle.Play(1)
+	again := dealCardCycle(cycle.Order())
+	assert.Equal(t, cycle, again)
+}
+
+func TestPlayCard(t *testing.T) {
+	knight := Move{Slot: 1, Lane: LaneRight}
+	for _, tt := range []struct {
+		name   string
+		move   Move
+		elixir float64
+		after  float64
+		action string // Empty when the play is refused
+	}{
+		{"pays the elixir cost", knight, 5, 2, "Player deployed Knight (Level 1) in the right lane"},
+		{"on a tile", Move{Slot: 1, Lane: LaneLeft, Tile: &Tile{X: 12, Y: 10}}, 5, 2, "Player deployed Knight (Level 1) in the right lane at 12,10"},
+		{"not enough elixir", knight, 2.9, 2.9, ""},
+		{"no such slot", Move{Slot: 4}, 10, 10, ""},
+		{"tile in the river", Move{Slot: 1, Tile: &Tile{X: 12, Y: 15}}, 10, 10, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			deck := []clash.Card{{Name: "Giant", Level: 1}, {Name: "Knight", Level: 1}, {Name: "Archers", Level: 1},
+				{Name: "Minions", Level: 1}, {Name: "Zap", Level: 1}}
+			state := GameState{
+				PlayerTowers: newTowers(SidePlayer),
+				EnemyTowers:  newTowers(SideEnemy),
+				PlayerName:   "Player",
+				PlayerElixir: tt.elixir,
+				Rules:        rulesets["ladder"],
+				Elapsed:      time.Second,
+				PlayerCycle:  dealCardCycle(deck),
+			}
+			action, err := playCard(&state, SidePlayer, tt.move)
+			assert.Equal(t, tt.after, state.PlayerElixir)
+			if tt.action == "" {
+				assert.Error(t, err)
+				assert.Empty(t, state.Plays)
+				assert.Empty(t, state.Units)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.action, action)
+			assert.Equal(t, []CardPlay{{Side: SidePlayer, Card: deck[1], Lane: LaneRight, Tile: tt.move.Tile, At: time.Second}}, state.Plays)
+			assert.Equal(t, "Zap", state.PlayerCycle.Hand[1].Name)
+			assert.Len(t, state.Units, 1)
+		})
+	}
+}
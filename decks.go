@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	// Connects to players.go and refs.go: Opponent decks come from clash.Player and clash.Battles
+	"github.com/fiskie/go-clash/clash"
+)
+
+// fetchOpponentDeck looks up the deck an opponent actually plays. The profile's current
+// deck is used first; when it is empty the deck from their most recent battle is used.
+// It returns the deck and where it came from.
+func fetchOpponentDeck(players *clash.Resolver, tag string) ([]clash.Card, string, error) {
+	if players == nil || tag == "" {
+		return nil, "", fmt.Errorf("no player tag to look up")
+	}
+
+	// Connects to refs.go: Resolver.Player fetches each profile once
+	profile, err := players.Player(tag)
+	if err == nil && len(profile.CurrentDeck) > 0 {
+		return profile.CurrentDeck, "current deck", nil
+	}
+
+	// Connects to players.go: Calls PlayerAPI.BattleLog
+	battles, logErr := players.API.Player(tag).BattleLog()
+	if logErr != nil {
+		if err != nil {
+			return nil, "", fmt.Errorf("fetching profile: %v; fetching battle log: %v", err, logErr)
+		}
+		return nil, "", fmt.Errorf("fetching battle log: %v", logErr)
+	}
+	if deck := lastBattleDeck(battles, tag); len(deck) > 0 {
+		return deck, "most recent battle", nil
+	}
+	return nil, "", fmt.Errorf("no deck found for %s", clash.NormaliseTag(tag))
+}
+
+// opponentDeck returns the deck an opponent plays: the one their ref already knows,
+// their own from the API, or a copy of the player's deck when theirs can't be found.
+// It also returns where an API deck came from.
+func opponentDeck(players *clash.Resolver, player clash.Player, opponent clash.PlayerRef, logger *Logger) ([]clash.Card, string) {
+	if len(opponent.Deck) > 0 {
+		return opponent.Deck, ""
+	}
+	deck, source, err := fetchOpponentDeck(players, opponent.Tag)
+	if err == nil {
+		return deck, source
+	}
+	if opponent.Tag != "" {
+		logger.Error("Error fetching %s's deck: %v", opponent.Name, err)
+	}
+	return player.CurrentDeck, "" // Simulate opponent using same deck
+}
+
+// clanOpponent picks a random member of the player's clan other than the player. It
+// reports false when the player is the only member.
+func clanOpponent(members []clash.ClanMember, player clash.Player, rng *rand.Rand) (clash.PlayerRef, bool) {
+	for _, i := range rng.Perm(len(members)) {
+		if clash.NormaliseTag(members[i].Tag) != clash.NormaliseTag(player.Tag) {
+			opponent := members[i].Ref()
+			opponent.Clan = player.Clan
+			return opponent, true
+		}
+	}
+	return clash.PlayerRef{}, false
+}
+
+// withTrophies fills in an opponent's ladder trophies from their profile, for refs like
+// tournament members that only carry a score. Without a profile they stay unknown.
+func withTrophies(players *clash.Resolver, opponent clash.PlayerRef) clash.PlayerRef {
+	if opponent.Trophies == 0 && opponent.Tag != "" && players != nil {
+		if profile, err := players.Player(opponent.Tag); err == nil {
+			opponent.Trophies = profile.Trophies
+		}
+	}
+	return opponent
+}
+
+// lastBattleDeck returns the cards a player used in their most recent battle
+func lastBattleDeck(battles clash.Battles, tag string) []clash.Card {
+	recent := make(clash.Battles, len(battles))
+	copy(recent, battles)
+	sort.SliceStable(recent, func(i, j int) bool {
+		return recent[i].BattleTime().After(recent[j].BattleTime())
+	})
+	for i := range recent {
+		if player, err := recent[i].PlayerByTag(tag); err == nil && len(player.Cards) > 0 {
+			return player.Cards
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClanOpponent(t *testing.T) {
+	player := clash.Player{Tag: "#2PP", Clan: clash.PlayerClan{Tag: "#8QU"}}
+	rng := rand.New(rand.NewSource(1))
+
+	// The player never meets themselves, even written another way
+	_, ok := clanOpponent([]clash.ClanMember{{Tag: "2pp"}}, player, rng)
+	assert.False(t, ok)
+	_, ok = clanOpponent(nil, player, rng)
+	assert.False(t, ok)
+
+	for i := 0; i < 10; i++ {
+		opponent, ok := clanOpponent([]clash.ClanMember{{Tag: "#2PP"}, {Tag: "#9LL", Name: "Other"}}, player, rng)
+		assert.True(t, ok)
+		assert.Equal(t, "#9LL", opponent.Tag)
+		assert.Equal(t, "#8QU", opponent.Clan.Tag)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LineReader reads stdin a line at a time for the menus and for matches played in line
+// mode. A line is only read when someone asks for it, so nothing is read while a raw
+// keyboard has the terminal, and a line asked for by a match that has since ended goes
+// to the menu that asks next instead of being swallowed.
+type LineReader struct {
+	mu    sync.Mutex
+	asks  chan struct{}
+	lines chan string
+	asked bool // A line was asked for and hasn't been taken yet
+	eof   bool
+	text  string
+}
+
+// newLineReader starts reading lines from r as they are asked for
+func newLineReader(r io.Reader) *LineReader {
+	lr := &LineReader{asks: make(chan struct{}, 1), lines: make(chan string)}
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for range lr.asks {
+			if !scanner.Scan() {
+				close(lr.lines)
+				return
+			}
+			lr.lines <- scanner.Text()
+		}
+	}()
+	return lr
+}
+
+// next returns the next line, or false when the input ends or stop is closed first
+func (lr *LineReader) next(stop <-chan struct{}) (string, bool) {
+	lr.mu.Lock()
+	if lr.eof {
+		lr.mu.Unlock()
+		return "", false
+	}
+	if !lr.asked {
+		lr.asked = true
+		lr.asks <- struct{}{}
+	}
+	lr.mu.Unlock()
+
+	select {
+	case line, ok := <-lr.lines:
+		lr.mu.Lock()
+		lr.asked, lr.eof = false, !ok
+		lr.mu.Unlock()
+		return line, ok
+	case <-stop:
+		return "", false
+	}
+}
+
+// Scan reads the next line for Text, like bufio.Scanner. It reports false once the
+// input ends.
+func (lr *LineReader) Scan() bool {
+	var ok bool
+	lr.text, ok = lr.next(nil)
+	return ok
+}
+
+// Text returns the line read by the last Scan
+func (lr *LineReader) Text() string {
+	return lr.text
+}
+
+// Keyboard delivers player input during a match. On a terminal it reads single key
+// presses ("1", "l", "left", "enter", "backspace", "ctrl-c"); otherwise it falls back
+// to whole lines.
+type Keyboard struct {
+	lines   *LineReader
+	keys    chan string
+	raw     bool
+	restore func()
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newKeyboard starts reading from stdin, in raw mode when stdin is a terminal and from
+// the shared line reader otherwise
+func newKeyboard(lines *LineReader) *Keyboard {
+	k := &Keyboard{lines: lines, keys: make(chan string), stop: make(chan struct{}), done: make(chan struct{})}
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		go k.readLines()
+		return k
+	}
+	k.raw, k.restore = true, restore
+	go k.readKeys()
+	return k
+}
+
+// Keys returns the channel key presses, or lines in line mode, arrive on
+func (k *Keyboard) Keys() <-chan string {
+	return k.keys
+}
+
+// Raw reports whether the keyboard reads single key presses
+func (k *Keyboard) Raw() bool {
+	return k.raw
+}
+
+// Close stops reading and restores the terminal. In raw mode the reader stops before
+// the terminal is restored so it doesn't swallow input meant for the menus.
+func (k *Keyboard) Close() {
+	select {
+	case <-k.stop:
+		return
+	default:
+	}
+	close(k.stop)
+	if k.raw {
+		select {
+		case <-k.done:
+		case <-time.After(time.Second):
+		}
+		k.restore()
+	}
+}
+
+// readLines sends every line typed until the keyboard is closed. A line still being
+// waited for then is left to the next reader.
+func (k *Keyboard) readLines() {
+	for {
+		line, ok := k.lines.next(k.stop)
+		if !ok {
+			return
+		}
+		select {
+		case k.keys <- strings.TrimSpace(line):
+		case <-k.stop:
+			return
+		}
+	}
+}
+
+// readKeys sends every key pressed until the keyboard is closed. Reads time out every
+// tenth of a second so the stop signal is noticed.
+func (k *Keyboard) readKeys() {
+	defer close(k.done)
+	buf := make([]byte, 16)
+	for {
+		select {
+		case <-k.stop:
+			return
+		default:
+		}
+		n, _ := os.Stdin.Read(buf)
+		for _, key := range parseKeys(buf[:n]) {
+			select {
+			case k.keys <- key:
+			case <-k.stop:
+				return
+			}
+		}
+	}
+}
+
+// arrowKeys names the escape sequences sent by the arrow keys
+var arrowKeys = map[byte]string{'A': "up", 'B': "down", 'C': "right", 'D': "left"}
+
+// parseKeys splits raw terminal input into key names
+func parseKeys(input []byte) []string {
+	var keys []string
+	for i := 0; i < len(input); i++ {
+		switch b := input[i]; {
+		case b == 0x1b && i+2 < len(input) && input[i+1] == '[':
+			if name, exists := arrowKeys[input[i+2]]; exists {
+				keys = append(keys, name)
+			}
+			i += 2
+		case b == 0x1b:
+			keys = append(keys, "esc")
+		case b == 0x03:
+			keys = append(keys, "ctrl-c")
+		case b == '\r' || b == '\n':
+			keys = append(keys, "enter")
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, "backspace")
+		case b >= ' ' && b < 0x7f:
+			keys = append(keys, strings.ToLower(string(b)))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		keys  []string
+	}{
+		{"3L", []string{"3", "l"}},
+		{"\x1b[D\x1b[C", []string{"left", "right"}},
+		{"\x1b", []string{"esc"}},
+		{"\x03\r\x7f", []string{"ctrl-c", "enter", "backspace"}},
+		{"\x01", nil},
+	} {
+		assert.Equal(t, tt.keys, parseKeys([]byte(tt.input)), "%q", tt.input)
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	in, out := io.Pipe()
+	lines := newLineReader(in)
+
+	// A match stops waiting for a line...
+	stop := make(chan struct{})
+	waited := make(chan bool)
+	go func() {
+		_, ok := lines.next(stop)
+		waited <- ok
+	}()
+	close(stop)
+	assert.False(t, <-waited)
+
+	// ...and the line typed next goes to the menu instead
+	go io.WriteString(out, "2\nbye\n")
+	assert.True(t, lines.Scan())
+	assert.Equal(t, "2", lines.Text())
+	assert.True(t, lines.Scan())
+	assert.Equal(t, "bye", lines.Text())
+
+	// A keyboard in line mode reads from the same reader
+	go io.WriteString(out, "later\n")
+	keyboard := &Keyboard{lines: lines, keys: make(chan string), stop: make(chan struct{})}
+	go keyboard.readLines()
+	assert.Equal(t, "later", <-keyboard.Keys())
+	keyboard.Close()
+
+	out.Close()
+	assert.False(t, lines.Scan())
+	assert.False(t, lines.Scan())
+}
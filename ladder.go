@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	// Connects to players.go and locations.go: The ladder uses clash.Arena, clash.LeagueStats and clash.PlayerRanking
+	"github.com/fiskie/go-clash/clash"
+)
+
+// ladderArena is an arena of the trophy road and the trophies it opens at
+type ladderArena struct {
+	clash.Arena
+	Trophies int
+}
+
+// arenas is the trophy road, lowest first. Once a player reaches an arena its gate
+// keeps them from dropping back below it.
+var arenas = []ladderArena{
+	{clash.Arena{ID: 54000001, Name: "Goblin Stadium"}, 0},
+	{clash.Arena{ID: 54000002, Name: "Bone Pit"}, 300},
+	{clash.Arena{ID: 54000003, Name: "Barbarian Bowl"}, 600},
+	{clash.Arena{ID: 54000004, Name: "P.E.K.K.A's Playhouse"}, 1000},
+	{clash.Arena{ID: 54000005, Name: "Spell Valley"}, 1300},
+	{clash.Arena{ID: 54000006, Name: "Builder's Workshop"}, 1600},
+	{clash.Arena{ID: 54000007, Name: "Royal Arena"}, 2000},
+	{clash.Arena{ID: 54000008, Name: "Frozen Peak"}, 2300},
+	{clash.Arena{ID: 54000009, Name: "Jungle Arena"}, 2600},
+	{clash.Arena{ID: 54000010, Name: "Hog Mountain"}, 3000},
+	{clash.Arena{ID: 54000011, Name: "Electro Valley"}, 3400},
+	{clash.Arena{ID: 54000012, Name: "Spooky Town"}, 3800},
+	{clash.Arena{ID: 54000013, Name: "Legendary Arena"}, 5000},
+}
+
+const (
+	ladderK          = 60   // Trophies at stake: a win against an equal opponent is worth half
+	maxTrophyChange  = 59   // Most trophies a single match can win or lose
+	seasonResetFloor = 5000 // Trophies above this are halved at the end of a season
+	matchWindow      = 100  // Trophy window matchmaking starts with; it doubles until someone fits
+	maxMatchWindow   = 3200
+)
+
+// arenaFor returns the arena a trophy count plays in
+func arenaFor(trophies int) ladderArena {
+	arena := arenas[0]
+	for _, a := range arenas {
+		if trophies >= a.Trophies {
+			arena = a
+		}
+	}
+	return arena
+}
+
+// ladderTrophyChange returns the trophies won or lost in a match, scaled by how
+// many trophies the opponent had: beating a stronger player is worth more and losing
+// to one costs less. Draws don't change trophies.
+func ladderTrophyChange(trophies, opponentTrophies int, crowns, opponentCrowns int) int {
+	if crowns == opponentCrowns {
+		return 0
+	}
+	expected := 1 / (1 + math.Pow(10, float64(opponentTrophies-trophies)/400))
+	if crowns > opponentCrowns {
+		return clampTrophies(int(math.Round(ladderK * (1 - expected))))
+	}
+	return -clampTrophies(int(math.Round(ladderK * expected)))
+}
+
+// clampTrophies keeps a trophy change between 1 and maxTrophyChange
+func clampTrophies(change int) int {
+	return min(max(change, 1), maxTrophyChange)
+}
+
+// seasonID returns the id of the season a moment falls in, in the API's "2006-01" form
+func seasonID(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// LadderPlayer is an opponent on the local ladder
+type LadderPlayer struct {
+	Tag      string
+	Name     string
+	Trophies int
+	Arena    clash.Arena
+	Clan     clash.PlayerClan
+	Deck     []clash.Card // Known for local players, fetched for ranked ones
+}
+
+// Ref returns the ladder player as playGame is given them, at their current trophies
+func (p *LadderPlayer) Ref() clash.PlayerRef {
+	return clash.PlayerRef{Tag: p.Tag, Name: p.Name, Trophies: p.Trophies, Clan: p.Clan, Deck: p.Deck}
+}
+
+// Ladder is the pool of players ranked matches are found in
+type Ladder struct {
+	Players []*LadderPlayer
+	last    *LadderPlayer // Not matched twice in a row
+	rng     *rand.Rand
+}
+
+// newLadder builds a ladder from a location's rankings and the local players, leaving
+// out the human
+func newLadder(rankings []clash.PlayerRanking, locals []clash.Player, humanTag string, seed int64) *Ladder {
+	ladder := &Ladder{rng: rand.New(rand.NewSource(seed))}
+	seen := map[string]bool{clash.NormaliseTag(humanTag): true}
+	add := func(p *LadderPlayer) {
+		if seen[clash.NormaliseTag(p.Tag)] {
+			return
+		}
+		seen[clash.NormaliseTag(p.Tag)] = true
+		p.Arena = arenaFor(p.Trophies).Arena
+		ladder.Players = append(ladder.Players, p)
+	}
+	for _, r := range rankings {
+		add(&LadderPlayer{Tag: r.Tag, Name: r.Name, Trophies: r.Trophies, Clan: r.Clan})
+	}
+	for _, p := range locals {
+		add(&LadderPlayer{Tag: p.Tag, Name: p.Name, Trophies: p.Trophies, Clan: p.Clan, Deck: p.CurrentDeck})
+	}
+	return ladder
+}
+
+// Match finds an opponent within a trophy window around the player's trophies. The
+// window starts narrow and doubles until someone fits; it returns the window used.
+func (l *Ladder) Match(trophies int) (*LadderPlayer, int, error) {
+	if len(l.Players) == 0 {
+		return nil, 0, fmt.Errorf("the ladder has no players")
+	}
+	for window := matchWindow; ; window *= 2 {
+		var candidates []*LadderPlayer
+		for _, p := range l.Players {
+			diff := p.Trophies - trophies
+			if diff >= -window && diff <= window && (p != l.last || len(l.Players) == 1) {
+				candidates = append(candidates, p)
+			}
+		}
+		if len(candidates) > 0 {
+			l.last = candidates[l.rng.Intn(len(candidates))]
+			return l.last, window, nil
+		}
+		if window >= maxMatchWindow {
+			// Nobody is close: play whoever is nearest
+			nearest := append([]*LadderPlayer(nil), l.Players...)
+			sort.SliceStable(nearest, func(i, j int) bool {
+				return abs(nearest[i].Trophies-trophies) < abs(nearest[j].Trophies-trophies)
+			})
+			l.last = nearest[0]
+			return l.last, abs(l.last.Trophies - trophies), nil
+		}
+	}
+}
+
+// Settle applies an opponent's side of a match to their trophies and arena
+func (l *Ladder) Settle(p *LadderPlayer, change int) {
+	p.Trophies = max(arenaFor(p.Trophies).Trophies, p.Trophies+change)
+	p.Arena = arenaFor(p.Trophies).Arena
+}
+
+// abs returns the absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// loadLocalPlayers reads the local players, who play with the decks the file gives them
+func loadLocalPlayers(path string) ([]clash.Player, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var players []clash.Player
+	return players, json.Unmarshal(data, &players)
+}
+
+// buildLadder seeds the ladder with the rankings of a location and the local players
+func buildLadder(client clash.API, locals []clash.Player, humanTag, locationID string, seed int64, logger *Logger) *Ladder {
+	// Connects to locations.go: Fetches rankings via client.Location(locationID).PlayerRankings()
+	ranked, err := client.Location(locationID).PlayerRankings(&clash.PagedQuery{Limit: 200})
+	if err != nil {
+		logger.Error("Error fetching rankings: %v", err)
+	}
+	return newLadder(ranked.Items, locals, humanTag, seed)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLadderTrophyChange(t *testing.T) {
+	for _, tt := range []struct {
+		name                   string
+		trophies, opponent     int
+		crowns, opponentCrowns int
+		change                 int
+	}{
+		{"win against an equal", 4000, 4000, 1, 0, 30},
+		{"loss against an equal", 4000, 4000, 0, 3, -30},
+		{"draw", 4000, 4600, 1, 1, 0},
+		{"win against a stronger player", 4000, 4400, 2, 1, 55},
+		{"loss against a stronger player", 4000, 4400, 0, 1, -5},
+		// Lopsided matches are clamped to 1..59 trophies
+		{"win against a far stronger player", 3000, 6000, 1, 0, 59},
+		{"win against a far weaker player", 6000, 3000, 1, 0, 1},
+		{"loss against a far weaker player", 6000, 3000, 0, 1, -59},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.change, ladderTrophyChange(tt.trophies, tt.opponent, tt.crowns, tt.opponentCrowns))
+		})
+	}
+}
+
+func TestArenaFor(t *testing.T) {
+	for _, tt := range []struct {
+		trophies int
+		arena    string
+	}{
+		{0, "Goblin Stadium"},
+		{299, "Goblin Stadium"},
+		{300, "Bone Pit"},
+		{4999, "Spooky Town"},
+		{7000, "Legendary Arena"},
+	} {
+		assert.Equal(t, tt.arena, arenaFor(tt.trophies).Name, "%d trophies", tt.trophies)
+	}
+}
+
+func TestNewLadder(t *testing.T) {
+	rankings := []clash.PlayerRanking{{Tag: "#2PP", Name: "Human", Trophies: 5000}, {Tag: "#9LL", Name: "Ranked", Trophies: 6000}}
+	deck := []clash.Card{{Name: "Hog Rider"}}
+	locals := []clash.Player{
+		{Tag: "#9LL", Name: "Ranked twice", Trophies: 100},
+		{Tag: "#PLAYER1", Name: "Local", Trophies: 4000, CurrentDeck: deck},
+	}
+	ladder := newLadder(rankings, locals, "2pp", 1)
+	if assert.Len(t, ladder.Players, 2) {
+		assert.Equal(t, "Ranked", ladder.Players[0].Name)
+		assert.Equal(t, clash.PlayerRef{Tag: "#PLAYER1", Name: "Local", Trophies: 4000, Deck: deck}, ladder.Players[1].Ref())
+		assert.Equal(t, arenaFor(4000).Arena, ladder.Players[1].Arena)
+	}
+}
@@ -1,81 +1,205 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math/rand"
 	"os"
-	"os/exec"
-	"runtime"
 	"strings"
 	"time"
 
 	// Connects to provided files:
-	// - client.go: Provides Client, NewClient, SetLogLatencyFunc
+	// - client.go: Provides API, Client, NewClient
 	// - clans.go: Provides ClanService, CurrentWar, Members
 	// - locations.go: Provides LocationService, PlayerRankings
 	// - players.go: Provides Player, Card, PlayerClan, PlayerService
 	// - tournaments.go: Provides TournamentService, TournamentsService, Tournament
+	// - refs.go: Provides PlayerRef, Resolver
+	// - tags.go: Provides ParseTag
 	"github.com/fiskie/go-clash/clash"
 )
 
-// Tower represents a tower with stats as per TCR Appendix
-type Tower struct {
-	Type     string  `json:"type"`
-	HP       int     `json:"hp"`
-	ATK      int     `json:"atk"`
-	DEF      int     `json:"def"`
-	CRIT     float64 `json:"crit"` // Crit chance (0.1 for King, 0.05 for Guard)
-	MaxHP    int     `json:"max_hp"`
-}
+// CardType tells the battle engine how a card enters the arena
+type CardType int
+
+const (
+	CardTroop CardType = iota
+	CardSpell
+	CardBuilding
+)
 
 // CardStats defines detailed card information
 type CardStats struct {
-	ElixirCost  int
-	BaseDamage  int
-	HitPoints   int
-	CritChance  float64 // Crit chance for the card (0.05 to 0.15)
+	Type       CardType
+	ElixirCost int
+	BaseDamage int // Damage per hit, or per second for spells with a Duration
+	HitPoints  int
+	CritChance float64 // Crit chance for the card (0.05 to 0.15)
+	Speed      float64 // Tiles per second
+	HitSpeed   float64 // Seconds between attacks
+	Range      float64 // Attack range in tiles
+	Count      int     // Number of units spawned, 0 means one
+
+	// Targeting
+	TargetsBuildings bool // Ignores troops and walks straight for buildings and towers
+
+	// Spell properties
+	Radius     float64 // Area of effect in tiles around the target
+	CrownTower float64 // Fraction of the damage dealt to crown towers
+	Stun       float64 // Seconds hit units are stunned for
+	Duration   float64 // Seconds a lingering spell keeps pulsing
+	Knockback  float64 // Tiles hit units are pushed back
+	Roll       float64 // Tiles a rolling spell travels past its target
+
+	// Building properties
+	Lifetime       float64 // Seconds until the building has decayed completely
+	SpawnCard      string  // Card spawned periodically by a spawner building
+	SpawnInterval  float64 // Seconds between two spawned waves
+	SpawnCount     int     // Units per spawned wave
+	ElixirInterval float64 // Seconds between two elixir produced by an elixir collector
 }
 
 // cardDatabase maps card names to their stats
 var cardDatabase = map[string]CardStats{
-	"Giant":         {ElixirCost: 5, BaseDamage: 140, HitPoints: 2500, CritChance: 0.05},
-	"Musketeer":     {ElixirCost: 4, BaseDamage: 100, HitPoints: 600, CritChance: 0.10},
-	"Fireball":      {ElixirCost: 3, BaseDamage: 200, HitPoints: 0, CritChance: 0.15},
-	"Archers":       {ElixirCost: 3, BaseDamage: 120, HitPoints: 350, CritChance: 0.08},
-	"Knight":        {ElixirCost: 3, BaseDamage: 200, HitPoints: 800, CritChance: 0.08},
-	"Arrows":        {ElixirCost: 2, BaseDamage: 100, HitPoints: 0, CritChance: 0.10},
-	"Goblin Barrel": {ElixirCost: 3, BaseDamage: 60, HitPoints: 150, CritChance: 0.07},
-	"Minions":       {ElixirCost: 3, BaseDamage: 70, HitPoints: 200, CritChance: 0.09},
+	"Giant":            {ElixirCost: 5, BaseDamage: 140, HitPoints: 2500, CritChance: 0.05, Speed: 0.75, HitSpeed: 1.5, Range: 1.2, TargetsBuildings: true},
+	"Musketeer":        {ElixirCost: 4, BaseDamage: 100, HitPoints: 600, CritChance: 0.10, Speed: 1.0, HitSpeed: 1.0, Range: 6.0},
+	"Fireball":         {Type: CardSpell, ElixirCost: 3, BaseDamage: 200, HitPoints: 0, CritChance: 0.15, Radius: 2.5, CrownTower: 0.3, Knockback: 0.5},
+	"Archers":          {ElixirCost: 3, BaseDamage: 120, HitPoints: 350, CritChance: 0.08, Speed: 1.0, HitSpeed: 1.2, Range: 5.0, Count: 2},
+	"Knight":           {ElixirCost: 3, BaseDamage: 200, HitPoints: 800, CritChance: 0.08, Speed: 1.0, HitSpeed: 1.2, Range: 1.2},
+	"Arrows":           {Type: CardSpell, ElixirCost: 2, BaseDamage: 100, HitPoints: 0, CritChance: 0.10, Radius: 4.0, CrownTower: 0.3},
+	"Goblin Barrel":    {ElixirCost: 3, BaseDamage: 60, HitPoints: 150, CritChance: 0.07, Speed: 2.0, HitSpeed: 1.1, Range: 0.5, Count: 3},
+	"Minions":          {ElixirCost: 3, BaseDamage: 70, HitPoints: 200, CritChance: 0.09, Speed: 2.0, HitSpeed: 1.0, Range: 1.6, Count: 3},
+	"Zap":              {Type: CardSpell, ElixirCost: 2, BaseDamage: 75, CritChance: 0.05, Radius: 2.5, CrownTower: 0.3, Stun: 0.5},
+	"Poison":           {Type: CardSpell, ElixirCost: 4, BaseDamage: 60, CritChance: 0.05, Radius: 3.5, CrownTower: 0.3, Duration: 8},
+	"Log":              {Type: CardSpell, ElixirCost: 2, BaseDamage: 200, CritChance: 0.05, Radius: 1.0, CrownTower: 0.3, Knockback: 1.0, Roll: 10},
+	"Lightning":        {Type: CardSpell, ElixirCost: 6, BaseDamage: 400, CritChance: 0.10, Radius: 3.5, CrownTower: 0.3, Stun: 0.5},
+	"Skeletons":        {ElixirCost: 1, BaseDamage: 70, HitPoints: 70, CritChance: 0.05, Speed: 1.5, HitSpeed: 1.0, Range: 0.5, Count: 3},
+	"Fire Spirit":      {ElixirCost: 1, BaseDamage: 80, HitPoints: 90, CritChance: 0.05, Speed: 2.0, HitSpeed: 0.3, Range: 2.0},
+	"Cannon":           {Type: CardBuilding, ElixirCost: 3, BaseDamage: 130, HitPoints: 800, CritChance: 0.05, HitSpeed: 0.9, Range: 5.5, Lifetime: 30},
+	"Furnace":          {Type: CardBuilding, ElixirCost: 4, HitPoints: 900, Lifetime: 40, SpawnCard: "Fire Spirit", SpawnInterval: 5, SpawnCount: 1},
+	"Tombstone":        {Type: CardBuilding, ElixirCost: 3, HitPoints: 500, Lifetime: 30, SpawnCard: "Skeletons", SpawnInterval: 3.5, SpawnCount: 1},
+	"Elixir Collector": {Type: CardBuilding, ElixirCost: 6, HitPoints: 900, Lifetime: 65, ElixirInterval: 9},
 }
 
-// ReplayData stores simulated replay information
-type ReplayData struct {
-	Actions []string // List of actions (cards used)
-}
+// defaultCardStats is used for cards missing from cardDatabase
+var defaultCardStats = CardStats{ElixirCost: 3, BaseDamage: 50, HitPoints: 100, CritChance: 0.05, Speed: 1.0, HitSpeed: 1.2, Range: 1.2}
 
-// MockPlayer simulates the clash.Player structure from JSON
-// Connects to players.go: Mirrors clash.Player for Test Mode
-type MockPlayer struct {
-	Tag         string           `json:"tag"`
-	Name        string           `json:"name"`
-	ExpLevel    int              `json:"expLevel"`
-	Trophies    int              `json:"trophies"`
-	CurrentDeck []clash.Card     `json:"currentDeck"` // From players.go: clash.Card
-	Clan        clash.PlayerClan `json:"clan"`        // From players.go: clash.PlayerClan
+// lookupCardStats returns the stats of a card, falling back to defaultCardStats
+func lookupCardStats(name string) CardStats {
+	if stats, exists := cardDatabase[name]; exists {
+		return stats
+	}
+	return defaultCardStats
 }
 
+// defaultOpponent is played when a mode finds nobody to play against. Without a tag
+// it plays a copy of the player's deck.
+var defaultOpponent = clash.PlayerRef{Name: "Default Enemy", Trophies: 1000}
+
 // GameState stores the game state
 type GameState struct {
 	PlayerTowers []Tower
 	EnemyTowers  []Tower
 	PlayerElixir float64
 	EnemyElixir  float64
+	PlayerName   string
+	EnemyName    string
+	Rules        Ruleset
+	Elapsed      time.Duration
+	Crowns       CrownTracker
+	PlayerCycle  *CardCycle
+	EnemyCycle   *CardCycle
+	Plays        []CardPlay
+	Units        []*Unit
+	Effects      []*SpellEffect
+	Seed         int64 // Seeds every random roll of the match so it can be replayed
+	rng          *rand.Rand
+	strategyRngs [2]*rand.Rand
+	nextUnitID   int
+}
+
+// newGameState sets up a match: both sides' towers and starting elixir, and card cycles
+// shuffled with the match's random source
+func newGameState(rules Ruleset, seed int64, playerName, enemyName string, playerDeck, enemyDeck []clash.Card) GameState {
+	rng := rand.New(rand.NewSource(seed))
+	return GameState{
+		PlayerTowers: newTowers(SidePlayer),
+		EnemyTowers:  newTowers(SideEnemy),
+		PlayerElixir: rules.StartingElixir,
+		EnemyElixir:  rules.StartingElixir,
+		PlayerName:   playerName,
+		EnemyName:    enemyName,
+		Rules:        rules,
+		PlayerCycle:  newCardCycle(playerDeck, rng),
+		EnemyCycle:   newCardCycle(enemyDeck, rng),
+		Seed:         seed,
+		rng:          rng,
+	}
+}
+
+// random returns the match's random source. Crits and shuffles use it, never the global
+// source, so a match replays the same from its seed.
+func (s *GameState) random() *rand.Rand {
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewSource(s.Seed))
+	}
+	return s.rng
+}
+
+// strategyRandom returns the random source a side's strategy decides with. It is seeded
+// from the match seed but kept apart from random, because a replay re-simulates the
+// recorded plays without the strategies and must roll the same crits.
+func (s *GameState) strategyRandom(side Side) *rand.Rand {
+	if s.strategyRngs[side] == nil {
+		s.strategyRngs[side] = rand.New(rand.NewSource(s.Seed + int64(side) + 1))
+	}
+	return s.strategyRngs[side]
+}
+
+// towers returns the towers owned by a side
+func (s *GameState) towers(side Side) []Tower {
+	if side == SidePlayer {
+		return s.PlayerTowers
+	}
+	return s.EnemyTowers
+}
+
+// cycle returns the card cycle of a side
+func (s *GameState) cycle(side Side) *CardCycle {
+	if side == SidePlayer {
+		return s.PlayerCycle
+	}
+	return s.EnemyCycle
+}
+
+// elixir returns the elixir a side currently holds
+func (s *GameState) elixir(side Side) float64 {
+	if side == SidePlayer {
+		return s.PlayerElixir
+	}
+	return s.EnemyElixir
+}
+
+// addElixir gives elixir to a side, up to the ruleset's maximum
+func (s *GameState) addElixir(side Side, amount float64) {
+	if side == SidePlayer {
+		s.PlayerElixir = minFloat(s.PlayerElixir+amount, s.Rules.MaxElixir)
+	} else {
+		s.EnemyElixir = minFloat(s.EnemyElixir+amount, s.Rules.MaxElixir)
+	}
 }
 
+// sideName returns the name used for a side in the replay
+func (s *GameState) sideName(side Side) string {
+	if side == SidePlayer {
+		return s.PlayerName
+	}
+	return s.EnemyName
+}
+
+// modeNames names the menu's game modes in the match history
+var modeNames = map[string]string{"1": "normal", "2": "tournament", "3": "ranked", "4": "clan war"}
+
 func main() {
 	// Initialize logger
 	// Connects to client.go: Used for logging API errors/info
@@ -84,95 +208,94 @@ func main() {
 		errorLog: log.New(os.Stderr, "ERROR: ", log.LstdFlags),
 	}
 
+	// "goclash <command>" runs a single command instead of starting the game
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	// The config file's profile fills in the token, tag and preferences
+	settings, err := loadSettings("")
+	if err != nil {
+		logger.Error("Error reading config: %v", err)
+	}
+
 	// Declare player
 	// Connects to players.go: clash.Player stores player data
 	var player clash.Player
 
 	// Select mode (test or live)
 	fmt.Print("Select mode (1: Live Mode, 2: Test Mode): ")
-	scanner := bufio.NewScanner(os.Stdin)
+	scanner := newLineReader(os.Stdin)
 	scanner.Scan()
 	modeChoice := strings.TrimSpace(scanner.Text())
 	isTestMode := modeChoice == "2"
 
-	var client *clash.Client
-	var mockPlayers []MockPlayer
+	// Connects to client.go: Any clash.API will do, the live client or the fixture one
+	var client clash.API
 
 	if isTestMode {
-		// Read data from player.json
-		data, err := ioutil.ReadFile("player.json")
-		if err != nil {
-			logger.Error("Error reading player.json: %v", err)
-			fmt.Println("Unable to read player.json. Exiting program.")
-			return
-		}
-		if err := json.Unmarshal(data, &mockPlayers); err != nil {
-			logger.Error("Error parsing player.json: %v", err)
-			fmt.Println("Unable to parse player.json. Exiting program.")
-			return
+		// Test mode answers every request from the fixture directory, so all modes work offline
+		client = settings.newFixtureClient(logger)
+		fmt.Printf("Test Mode: using the fixtures in %s.\n", settings.fixtures())
+		// Connects to locations.go: The fixture rankings list the players to log in as
+		if ranked, err := client.Location("global").PlayerRankings(&clash.PagedQuery{Limit: 5}); err == nil && len(ranked.Items) > 0 {
+			var examples []string
+			for _, r := range ranked.Items {
+				examples = append(examples, fmt.Sprintf("%s (%s)", r.Tag, r.Name))
+			}
+			fmt.Printf("Players include %s.\n", strings.Join(examples, ", "))
 		}
 	} else {
-		// Enter API token
-		fmt.Print("Enter your API Token: ")
-		scanner.Scan()
-		apiToken := strings.TrimSpace(scanner.Text())
-		if apiToken == "" {
+		// Enter API token, unless the profile has one
+		if settings.Token == "" {
+			fmt.Print("Enter your API Token: ")
+			scanner.Scan()
+			settings.Token = strings.TrimSpace(scanner.Text())
+		}
+		if settings.Token == "" {
 			logger.Error("API Token cannot be empty")
 			return
 		}
 
-		// Connects to client.go: Initializes clash.Client with NewClient
-		client = clash.NewClient(apiToken, logger.Error, logger.Info)
-		// Connects to client.go: Sets API latency logging
-		client.SetLogLatencyFunc(func(statusCode, method, host, path string, elapsed time.Duration) {
-			logger.Info("Latency %s %s -> %s (%s): %v", method, host, path, statusCode, elapsed)
-		})
+		// Connects to client.go: Initializes clash.Client with the profile's base URL and timeout
+		client = settings.newClient(logger)
 	}
+	// Connects to refs.go: Opponents are fetched once per session
+	players := clash.NewResolver(client)
+	// The local players join the ladder, local tournaments and local river races
+	locals := settings.localPlayers(logger)
 
 	// Enter player tag
 	for {
-		fmt.Print("Enter player tag ( #ABC123): ")
+		if settings.Tag != "" {
+			fmt.Printf("Enter player tag ( #ABC123) [%s]: ", settings.Tag)
+		} else {
+			fmt.Print("Enter player tag ( #ABC123): ")
+		}
 		scanner.Scan()
 		playerTag := strings.TrimSpace(scanner.Text())
+		if playerTag == "" {
+			playerTag = settings.Tag
+		}
 		if playerTag == "" {
 			logger.Error("Player tag cannot be empty")
 			fmt.Println("Player tag cannot be empty. Please try again.")
 			continue
 		}
 
-		// Normalize tag
-		playerTag = strings.Replace(playerTag, "#", "%23", -1)
+		// Connects to tags.go: Typos in the tag are caught before asking the API
+		if _, err := clash.ParseTag(playerTag); err != nil {
+			fmt.Printf("%v. Please try again.\n", err)
+			continue
+		}
 
 		// Fetch player information
-		if isTestMode {
-			// Find player in mockPlayers
-			for _, mock := range mockPlayers {
-				if mock.Tag == strings.Replace(playerTag, "%23", "#", -1) {
-					player = clash.Player{
-						Tag:         mock.Tag,
-						Name:        mock.Name,
-						ExpLevel:    mock.ExpLevel,
-						Trophies:    mock.Trophies,
-						CurrentDeck: mock.CurrentDeck,
-						Clan:        mock.Clan,
-					}
-					break
-				}
-			}
-			if player.Tag == "" {
-				logger.Error("Player tag %s not found in player.json", playerTag)
-				fmt.Println("Player not found in player.json. Please enter a valid tag (e.g., #PLAYER1 or #PLAYER2).")
-				continue
-			}
-		} else {
-			// Connects to players.go: Fetches player data via client.Player(playerTag).Get()
-			var err error
-			player, err = client.Player(playerTag).Get()
-			if err != nil {
-				logger.Error("Error fetching player data: %v", err)
-				fmt.Println("Player not found. Check tag or API token. Please try again.")
-				continue
-			}
+		// Connects to players.go: Fetches player data via client.Player(playerTag).Get()
+		player, err = client.Player(playerTag).Get()
+		if err != nil {
+			logger.Error("Error fetching player data: %v", err)
+			fmt.Println("Player not found. Check tag or API token. Please try again.")
+			continue
 		}
 		break // Tag found, exit loop
 	}
@@ -181,48 +304,122 @@ func main() {
 	fmt.Printf("\nWelcome %s (Level %d, Trophies: %d)!\n", player.Name, player.ExpLevel, player.Trophies)
 	fmt.Println("Starting Clash Royale in terminal!")
 
+	// Local trophies and match history are kept between runs
+	profiles, err := loadProfiles(profilePath())
+	if err != nil {
+		logger.Error("Error loading profiles: %v", err)
+		profiles = &ProfileStore{path: profilePath(), Profiles: map[string]*Profile{}}
+	}
+	profile := profiles.Profile(player)
+	fmt.Printf("Local trophies: %d (%d wins, %d losses)\n", profile.Trophies, profile.Wins, profile.Losses)
+	player.Trophies = profile.Trophies
+
+	// record settles a finished match in the player's profile and saves it
+	record := func(replay *ReplayData, mode, replayPath string) {
+		if len(replay.Result.Team) == 0 || len(replay.Result.Opponent) == 0 {
+			return
+		}
+		arena := profile.Arena
+		match := profile.Record(&replay.Result, mode, replayPath)
+		player.Trophies = profile.Trophies
+		if trophyModes[mode] {
+			fmt.Printf("Trophies: %d (%+d)\n", match.Trophies, match.TrophyChange)
+		}
+		if profile.Arena.ID != arena.ID {
+			fmt.Printf("You are now in %s!\n", profile.Arena.Name)
+		}
+		if err := profiles.Save(); err != nil {
+			logger.Error("Error saving profile: %v", err)
+		}
+	}
+
+	// Every match is played with the profile's ruleset
+	rules := settings.rules()
+	var lastReplay *ReplayData
+	var ladder *Ladder // Built the first time Ranked Mode is picked
+	// Picks opponents from clans, tournaments and wars; matches have their own seeded sources
+	picker := rand.New(rand.NewSource(time.Now().UnixNano()))
+
 	// Main loop
 	for {
 		// Select game mode
 		fmt.Println("\nSelect game mode:")
 		fmt.Println("1. Normal Mode (Battle with clan members)")
-		if !isTestMode {
-			fmt.Println("2. Tournament Mode (Battle in tournaments)")
-			fmt.Println("3. Ranked Mode (Battle with ranked players)")
-			fmt.Println("4. Clan War Mode (Battle in clan wars)")
-		}
-		// Fixed syntax error: Simplified prompt range
-		promptRange := "1"
-		if !isTestMode {
-			promptRange = "1-4"
-		}
-		fmt.Printf("Enter number (%s): ", promptRange)
+		fmt.Println("2. Tournament Mode (Battle in tournaments)")
+		fmt.Println("3. Ranked Mode (Climb the ladder against players near your trophies)")
+		fmt.Println("4. Clan War Mode (Battle in clan wars)")
+		fmt.Println("5. Online Match (Host or join a match against another player)")
+		fmt.Println("6. Replays (Verify or watch a saved match)")
+		fmt.Println("7. History (Your record and recent matches)")
+		fmt.Println("8. Tournament Bracket (Play a whole tournament against AI-simulated members)")
+		fmt.Println("9. River Race (Play a clan war week with your clan)")
+		fmt.Print("Enter number (1-9): ")
 		scanner.Scan()
 		mode := strings.TrimSpace(scanner.Text())
 
-		var opponent interface{}
-		var opponentName string
-		var opponentTrophies int
+		if mode == "9" {
+			playRiverRace(scanner, client, players, player, locals, rules, logger, record)
+			continue
+		}
+
+		if mode == "8" {
+			playTournament(scanner, client, players, player, locals, rules, logger, record)
+			continue
+		}
 
-		if isTestMode {
-			// In test mode, only Normal Mode is supported with opponents from player.json
-			if mode != "1" {
-				fmt.Println("Test Mode only supports Normal Mode. Switching to Normal Mode.")
-				mode = "1"
+		if mode == "7" {
+			showHistory(profile, 20)
+			continue
+		}
+
+		if mode == "6" {
+			replayMenu(scanner)
+			continue
+		}
+
+		if mode == "5" {
+			// Online matches are played against another player over the network
+			replay, err := onlineMatch(scanner, player, rules, logger)
+			if err != nil {
+				logger.Error("Online match failed: %v", err)
+				fmt.Println("Online match failed. Returning to the menu.")
+				continue
 			}
-			if len(mockPlayers) > 1 {
-				rand.Seed(time.Now().UnixNano())
-				for {
-					opponent = mockPlayers[rand.Intn(len(mockPlayers))]
-					if opponent.(MockPlayer).Tag != player.Tag {
-						break
-					}
+			record(&replay, "online", storeReplay(&replay, logger))
+			if len(replay.Result.Team) > 0 {
+				outcome := replay.Result.Outcome()
+				team, enemy := replay.Result.Team[0], replay.Result.Opponent[0]
+				if outcome.IsDraw {
+					fmt.Printf("\nResult: draw, %d - %d\n", team.Crowns, enemy.Crowns)
+				} else {
+					fmt.Printf("\nResult: %s won, %d - %d\n", outcome.Winners[0].Name, team.Crowns, enemy.Crowns)
 				}
-				opponentName = opponent.(MockPlayer).Name
-				opponentTrophies = opponent.(MockPlayer).Trophies
+			}
+			continue
+		}
+
+		var opponent clash.PlayerRef
+		var ladderOpponent *LadderPlayer
+
+		if mode == "3" {
+			// Ranked Mode: an opponent close in trophies from the local ladder
+			if ladder == nil {
+				fmt.Printf("Enter location ID for the ladder (e.g., global or country code like 57000000) [%s]: ", settings.location())
+				scanner.Scan()
+				locationID := strings.TrimSpace(scanner.Text())
+				if locationID == "" {
+					locationID = settings.location()
+				}
+				ladder = buildLadder(client, locals, player.Tag, locationID, time.Now().UnixNano(), logger)
+				fmt.Printf("The ladder has %d players.\n", len(ladder.Players))
+			}
+			match, window, err := ladder.Match(player.Trophies)
+			if err == nil {
+				ladderOpponent = match
+				opponent = match.Ref()
+				fmt.Printf("Matched within %d trophies in %s.\n", window, arenaFor(player.Trophies).Name)
 			} else {
-				opponentName = "Default Enemy"
-				opponentTrophies = 1000
+				fmt.Printf("%v. Switching to default opponent.\n", err)
 			}
 		} else {
 			switch mode {
@@ -230,11 +427,9 @@ func main() {
 				if player.Clan.Tag != "" {
 					// Connects to clans.go: Fetches clan members via client.Clan(player.Clan.Tag).Members()
 					members, err := client.Clan(player.Clan.Tag).Members()
-					if err == nil && len(members.Items) > 0 {
-						rand.Seed(time.Now().UnixNano())
-						opponent = members.Items[rand.Intn(len(members.Items))]
-						opponentName = opponent.(clash.ClanMember).Name
-						opponentTrophies = opponent.(clash.ClanMember).Trophies
+					member, ok := clanOpponent(members.Items, player, picker)
+					if err == nil && ok {
+						opponent = member
 					} else {
 						fmt.Println("No clan members found. Switching to default opponent.")
 					}
@@ -244,56 +439,22 @@ func main() {
 				scanner.Scan()
 				tournamentInput := strings.TrimSpace(scanner.Text())
 				if tournamentInput != "" {
-					tournamentInput = strings.Replace(tournamentInput, "#", "%23", -1)
-					// Connects to tournaments.go: Fetches tournament via client.Tournament(tournamentInput).Get()
-					tournament, err := client.Tournament(tournamentInput).Get()
+					// Connects to tournaments.go: Looks the tournament up by tag, or searches it by name
+					tournament, err := findTournament(client, tournamentInput)
 					if err == nil && len(tournament.MembersList) > 0 {
-						rand.Seed(time.Now().UnixNano())
-						opponent = tournament.MembersList[rand.Intn(len(tournament.MembersList))]
-						opponentName = opponent.(clash.TournamentMember).Name
-						opponentTrophies = opponent.(clash.TournamentMember).Score
+						opponent = tournament.MembersList[picker.Intn(len(tournament.MembersList))].Ref()
+						opponent = withTrophies(players, opponent)
 					} else {
-						// Connects to tournaments.go: Searches tournaments via client.Tournaments().Search()
-						query := &clash.TournamentQuery{Name: tournamentInput}
-						tournaments, err := client.Tournaments().Search(query)
-						if err == nil && len(tournaments.Items) > 0 {
-							tournament = tournaments.Items[0]
-							if len(tournament.MembersList) > 0 {
-								opponent = tournament.MembersList[rand.Intn(len(tournament.MembersList))]
-								opponentName = opponent.(clash.TournamentMember).Name
-								opponentTrophies = opponent.(clash.TournamentMember).Score
-							}
-						} else {
-							fmt.Println("Tournament not found. Switching to default opponent.")
-						}
+						fmt.Println("Tournament not found. Switching to default opponent.")
 					}
 				}
-			case "3": // Ranked Mode
-				fmt.Print("Enter location ID (e.g., global or country code like 57000000): ")
-				scanner.Scan()
-				locationID := strings.TrimSpace(scanner.Text())
-				if locationID == "" {
-					locationID = "global"
-				}
-				// Connects to locations.go: Fetches rankings via client.Location(locationID).PlayerRankings()
-				rankings, err := client.Location(locationID).PlayerRankings(&clash.PagedQuery{Limit: 10})
-				if err == nil && len(rankings.Items) > 0 {
-					rand.Seed(time.Now().UnixNano())
-					opponent = rankings.Items[rand.Intn(len(rankings.Items))]
-					opponentName = opponent.(clash.PlayerRanking).Name
-					opponentTrophies = opponent.(clash.PlayerRanking).Trophies
-				} else {
-					fmt.Println("No ranked players found. Switching to default opponent.")
-				}
 			case "4": // Clan War Mode
 				if player.Clan.Tag != "" {
 					// Connects to clans.go: Fetches clan war via client.Clan(player.Clan.Tag).CurrentWar()
 					war, err := client.Clan(player.Clan.Tag).CurrentWar()
 					if err == nil && len(war.Participants) > 0 {
-						rand.Seed(time.Now().UnixNano())
-						opponent = war.Participants[rand.Intn(len(war.Participants))]
-						opponentName = opponent.(clash.WarParticipant).Name
-						opponentTrophies = 0
+						opponent = war.Participants[picker.Intn(len(war.Participants))].Ref()
+						opponent.Clan = player.Clan
 					} else {
 						fmt.Println("No clan war found. Switching to default opponent.")
 					}
@@ -306,16 +467,40 @@ func main() {
 		}
 
 		// Default opponent
-		if opponent == nil {
-			opponentName = "Default Enemy"
-			opponentTrophies = 1000
+		if opponent.Tag == "" {
+			opponent = defaultOpponent
 		}
 
-		fmt.Printf("Opponent: %s (Trophies: %d)\n", opponentName, opponentTrophies)
+		fmt.Printf("Opponent: %s (Trophies: %d)\n", opponent.Name, opponent.Trophies)
+
+		// Select how the opponent plays
+		fmt.Println("Select opponent strategy:")
+		fmt.Println("1. Random (plays a random card every 5 seconds)")
+		fmt.Println("2. Elixir-aware (saves up for the card it wants)")
+		fmt.Println("3. Counter-play (answers your cards)")
+		if lastReplay != nil {
+			fmt.Println("4. Scripted (replays your last match)")
+		}
+		fmt.Print("Enter number: ")
+		scanner.Scan()
+		strategy := settings.strategy()
+		if choice, err := parseInt(strings.TrimSpace(scanner.Text())); err == nil && choice >= 1 && choice <= len(opponentStrategies) {
+			strategy = opponentStrategies[choice-1]
+		}
+		ai, err := newOpponent(strategy, lastReplay)
+		if err != nil {
+			fmt.Printf("%v. Using the elixir-aware strategy.\n", err)
+			ai = &ElixirOpponent{}
+		}
 
 		// Play the game and store replay
 		// Connects to players.go: Uses clash.Player, clash.Card
-		replay := playGame(client, player, opponent, opponentName, logger, isTestMode)
+		replay := playGame(scanner, players, player, opponent, rules, time.Now().UnixNano(), ai, logger)
+		lastReplay = &replay
+		record(&replay, modeNames[mode], storeReplay(&replay, logger))
+		if ladderOpponent != nil && len(replay.Result.Opponent) > 0 {
+			ladder.Settle(ladderOpponent, replay.Result.Opponent[0].TrophyChange)
+		}
 
 		// Display replay
 		fmt.Println("\nMatch replay:")
@@ -323,6 +508,16 @@ func main() {
 			fmt.Printf("%d. %s\n", i+1, action)
 		}
 
+		// Connects to players.go: Battle.Outcome() works on simulated results too
+		outcome := replay.Result.Outcome()
+		team, enemy := replay.Result.Team[0], replay.Result.Opponent[0]
+		switch {
+		case outcome.IsDraw:
+			fmt.Printf("\nResult: draw, %d - %d\n", team.Crowns, enemy.Crowns)
+		default:
+			fmt.Printf("\nResult: %s won, %d - %d\n", outcome.Winners[0].Name, team.Crowns, enemy.Crowns)
+		}
+
 		fmt.Print("\nContinue playing? (y/n): ")
 		scanner.Scan()
 		if strings.ToLower(scanner.Text()) != "y" {
@@ -334,200 +529,173 @@ func main() {
 
 // playGame implements the game loop
 // Connects to players.go: Uses clash.Player, clash.Card
-func playGame(client *clash.Client, player clash.Player, opponent interface{}, opponentName string, logger *Logger, isTestMode bool) ReplayData {
+func playGame(scanner *LineReader, players *clash.Resolver, player clash.Player, opponent clash.PlayerRef, rules Ruleset, seed int64, ai Opponent, logger *Logger) ReplayData {
 	// Display deck
 	fmt.Println("\nYour deck:")
-	for i, card := range player.CurrentDeck {
-		stats, exists := cardDatabase[card.Name]
-		if !exists {
-			stats = CardStats{ElixirCost: 3, BaseDamage: 50, HitPoints: 100, CritChance: 0.05}
-		}
-		fmt.Printf("%d. %s (Level %d, Elixir: %d, Damage: %d, HP: %d, Crit: %.0f%%)\n",
-			i+1, card.Name, card.Level, stats.ElixirCost, stats.BaseDamage, stats.HitPoints, stats.CritChance*100)
-	}
-
-	// Initialize game state with towers
-	rand.Seed(time.Now().UnixNano())
-	state := GameState{
-		PlayerTowers: []Tower{
-			{Type: "Guard Tower 1", HP: 1000, ATK: 300, DEF: 100, CRIT: 0.05, MaxHP: 1000},
-			{Type: "Guard Tower 2", HP: 1000, ATK: 300, DEF: 100, CRIT: 0.05, MaxHP: 1000},
-			{Type: "King Tower", HP: 2000, ATK: 500, DEF: 300, CRIT: 0.1, MaxHP: 2000},
-		},
-		EnemyTowers: []Tower{
-			{Type: "Guard Tower 1", HP: 1000, ATK: 300, DEF: 100, CRIT: 0.05, MaxHP: 1000},
-			{Type: "Guard Tower 2", HP: 1000, ATK: 300, DEF: 100, CRIT: 0.05, MaxHP: 1000},
-			{Type: "King Tower", HP: 2000, ATK: 500, DEF: 300, CRIT: 0.1, MaxHP: 2000},
-		},
-		PlayerElixir: 10.0,
-		EnemyElixir:  10.0,
-	}
-	replay := ReplayData{Actions: []string{}}
-
-	// Channels for communication
-	inputChan := make(chan string)
-	quitChan := make(chan bool)
-	// Set elixir regeneration time to 1s
-	elixirTick := time.NewTicker(1000 * time.Millisecond) // 1s for 1 elixir
-	enemyActionTick := time.NewTicker(5 * time.Second)    // Opponent acts every 5s
-	scanner := bufio.NewScanner(os.Stdin)
-
-	// Goroutine to read player input
-	go func() {
-		for scanner.Scan() {
-			input := strings.TrimSpace(scanner.Text())
-			inputChan <- input
-			if input == "0" {
-				quitChan <- true
-				return
-			}
-		}
-	}()
+	for _, card := range player.CurrentDeck {
+		stats := lookupCardStats(card.Name)
+		fmt.Printf("- %s (Level %d, Elixir: %d, Damage: %d, HP: %d, Crit: %.0f%%)\n",
+			card.Name, card.Level, stats.ElixirCost, stats.BaseDamage, stats.HitPoints, stats.CritChance*100)
+	}
 
-	// Function to clear screen
-	clearScreen := func() {
-		cmd := exec.Command("clear") // Linux/Mac
-		if runtime.GOOS == "windows" {
-			cmd = exec.Command("cmd", "/c", "cls")
-		}
-		cmd.Stdout = os.Stdout
-		cmd.Run()
+	enemyDeck, source := opponentDeck(players, player, opponent, logger)
+	if source != "" {
+		fmt.Printf("\n%s plays their %s.\n", opponent.Name, source)
+	}
+
+	// Initialize game state with towers. Everything left to chance in the match comes
+	// from the seed, so the replay can play it again exactly.
+	state := newGameState(rules, seed, "Player", opponent.Name, player.CurrentDeck, enemyDeck)
+	if provider, ok := ai.(cycleProvider); ok {
+		state.EnemyCycle = provider.Cycle()
+		enemyDeck = state.EnemyCycle.Order()
+	}
+	replay := newReplay(&state, player, opponent, enemyDeck)
+
+	// The match is drawn full-screen and read key by key
+	ui := newTUI(scanner)
+	displayTick := time.NewTicker(frameInterval) // Redraw the arena
+	battleTick := time.NewTicker(tickInterval)   // Elixir regenerates, the opponent plays, units and towers move and fight
+
+	stopTickers := func() {
+		displayTick.Stop()
+		battleTick.Stop()
+		ui.Close()
+	}
+
+	// finish stops the match and records its result
+	finish := func(end MatchEnd) ReplayData {
+		stopTickers()
+		replay.finish(&state, end, player)
+		return replay
 	}
 
 	// Game loop
-	startTime := time.Now()
 	for {
 		select {
-		case <-quitChan:
-			fmt.Println("You surrendered!")
-			replay.Actions = append(replay.Actions, "Player surrendered")
-			elixirTick.Stop()
-			enemyActionTick.Stop()
-			return replay
-
-		case input := <-inputChan:
+		case input := <-ui.Keys():
 			// Parse input
-			choice, err := parseInt(input)
-			if err != nil || choice < 1 || choice > len(player.CurrentDeck) {
-				fmt.Println("Invalid choice. Please select a number from 1 to", len(player.CurrentDeck))
+			cmd, ok, err := ui.Command(input, len(state.PlayerCycle.Hand))
+			if err != nil {
+				ui.Say("Invalid choice: %v.", err)
+			}
+			if !ok {
+				ui.Render(&state)
 				continue
 			}
-
-			// Get selected card
-			// Connects to players.go: Uses clash.Card from player.CurrentDeck
-			selectedCard := player.CurrentDeck[choice-1]
-			stats, exists := cardDatabase[selectedCard.Name]
-			if !exists {
-				stats = CardStats{ElixirCost: 3, BaseDamage: 50, HitPoints: 100, CritChance: 0.05}
+			if cmd.Surrender {
+				stopTickers()
+				fmt.Println("You surrendered!")
+				replay.Actions = append(replay.Actions, "Player surrendered")
+				replay.Surrender = &Surrender{Side: SidePlayer, At: state.Elapsed}
+				state.Crowns.surrender(SidePlayer)
+				return finish(surrenderEnd(SidePlayer, false))
 			}
 
-			// Check elixir
-			if float64(stats.ElixirCost) > state.PlayerElixir {
-				fmt.Printf("Not enough elixir! Need %d, you have %.1f.\n", stats.ElixirCost, state.PlayerElixir)
+			// Play the card from the hand and save the action to the replay
+			// Connects to players.go: Uses clash.Card from player.CurrentDeck
+			action, err := playCard(&state, SidePlayer, cmd.Move)
+			if err != nil {
+				ui.Say("Can't play that card: %v.", err)
+				ui.Render(&state)
 				continue
 			}
+			replay.Actions = append(replay.Actions, action)
+			ui.Say("")
+			ui.Log(action)
+			ui.Render(&state)
 
-			// Calculate and apply damage
-			damage, cardCrit, towerCrit := calculateDamage(selectedCard, stats, state.EnemyTowers)
-			result := applyDamage(&state.EnemyTowers, damage)
-			state.PlayerElixir -= float64(stats.ElixirCost)
-
-			// Save action to replay
-			critText := ""
-			if cardCrit && towerCrit {
-				critText = " (Double CRIT)"
-			} else if cardCrit {
-				critText = " (Card CRIT)"
-			} else if towerCrit {
-				critText = " (Tower CRIT)"
+		case <-battleTick.C:
+			// Opponent's turn
+			if move, ok := ai.Decide(&state, SideEnemy, state.strategyRandom(SideEnemy)); ok {
+				if action, err := playCard(&state, SideEnemy, move); err == nil {
+					replay.Actions = append(replay.Actions, action)
+					ui.Log(action)
+				}
 			}
-			action := fmt.Sprintf("Player used %s (Level %d) dealing %d damage%s to %s", selectedCard.Name, selectedCard.Level, damage, critText, result)
-			replay.Actions = append(replay.Actions, action)
 
-			// Display state
-			clearScreen()
-			displayGameState(state)
-			fmt.Printf("You used %s (Level %d) dealing %d damage%s to %s!\n", selectedCard.Name, selectedCard.Level, damage, critText, result)
+			// Regenerate elixir, move units, let them fight and let towers shoot
+			events := advanceMatch(&state, tickInterval)
+			replay.Actions = append(replay.Actions, events...)
+			ui.Log(events...)
 
 			// Check for end
-			if isKingTowerDestroyed(state.EnemyTowers) {
-				fmt.Println("\nCongratulations! You destroyed the opponent's King Tower!")
-				replay.Actions = append(replay.Actions, "Player won the match")
-				elixirTick.Stop()
-				enemyActionTick.Stop()
-				return replay
+			end, events := checkMatchEnd(&state)
+			replay.Actions = append(replay.Actions, events...)
+			ui.Log(events...)
+			if !end.Over {
+				continue
 			}
+			stopTickers()
+			switch {
+			case end.Draw:
+				fmt.Printf("\nMatch ended! Draw: %s.\n", end.Reason)
+				replay.Actions = append(replay.Actions, "Match ended in a draw")
+			case end.Winner == SidePlayer:
+				fmt.Printf("\nCongratulations! You %s!\n", end.Reason)
+				replay.Actions = append(replay.Actions, "Player won the match ("+end.Reason+")")
+			default:
+				fmt.Printf("\nYou lost! %s %s.\n", opponent.Name, end.Reason)
+				replay.Actions = append(replay.Actions, "Opponent won the match ("+end.Reason+")")
+			}
+			return finish(end)
 
-		case <-elixirTick.C:
-			// Regenerate elixir
-			state.PlayerElixir = minFloat(state.PlayerElixir+1.0, 10.0)
-			state.EnemyElixir = minFloat(state.EnemyElixir+1.0, 10.0)
-
-			// Display state
-			clearScreen()
-			displayGameState(state)
-			fmt.Println("Select a card to attack (enter number from 1 to 8, or 0 to surrender): ")
-
-		case <-enemyActionTick.C:
-			// Opponent's turn
-			if !isKingTowerDestroyed(state.PlayerTowers) {
-				var enemyDeck []clash.Card
-				if isTestMode && opponent != nil {
-					// Connects to players.go: Uses clash.Card from MockPlayer.CurrentDeck
-					enemyDeck = opponent.(MockPlayer).CurrentDeck
-				} else {
-					enemyDeck = player.CurrentDeck // Simulate opponent using same deck
-				}
-				enemyDamage, cardCrit, towerCrit, enemyAction := simulateEnemyTurn(enemyDeck, state.EnemyElixir, opponentName, state.PlayerTowers)
-				if enemyDamage > 0 {
-					result := applyDamage(&state.PlayerTowers, enemyDamage)
-					state.EnemyElixir -= 3
-					critText := ""
-					if cardCrit && towerCrit {
-						critText = " (Double CRIT)"
-					} else if cardCrit {
-						critText = " (Card CRIT)"
-					} else if towerCrit {
-						critText = " (Tower CRIT)"
-					}
-					fullAction := fmt.Sprintf("%s dealing %d damage%s to %s", enemyAction, enemyDamage, critText, result)
-					replay.Actions = append(replay.Actions, fullAction)
+		case <-displayTick.C:
+			ui.Render(&state)
+		}
+	}
+}
 
-					// Display state
-					clearScreen()
-					displayGameState(state)
-					fmt.Printf("Opponent %s used a card dealing %d damage%s to %s!\n", opponentName, enemyDamage, critText, result)
-				}
+// parseDeployCommand parses player input such as "3 L", "3 L 4,10" or "3 4,10" into a
+// card number, a lane and an optional tile. The lane defaults to left, or to the tile's
+// lane when a tile is given.
+func parseDeployCommand(input string) (int, Lane, *Tile, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || len(fields) > 3 {
+		return 0, LaneLeft, nil, fmt.Errorf("expected a card number, a lane and a tile, got %q", input)
+	}
+	choice, err := parseInt(fields[0])
+	if err != nil {
+		return 0, LaneLeft, nil, err
+	}
 
-				// Check for end
-				if isKingTowerDestroyed(state.PlayerTowers) {
-					fmt.Println("\nYou lost! Your King Tower was destroyed.")
-					replay.Actions = append(replay.Actions, "Opponent won the match")
-					elixirTick.Stop()
-					enemyActionTick.Stop()
-					return replay
-				}
+	lane, laneGiven := LaneLeft, false
+	var tile *Tile
+	for _, field := range fields[1:] {
+		if strings.Contains(field, ",") {
+			parsed, err := parseTile(field)
+			if err != nil {
+				return 0, LaneLeft, nil, err
 			}
+			tile = &parsed
+			continue
 		}
-
-		// Check for draw (3-minute time limit)
-		if time.Since(startTime) > 3*time.Minute {
-			fmt.Println("\nMatch ended! Draw.")
-			replay.Actions = append(replay.Actions, "Match ended in a draw")
-			elixirTick.Stop()
-			enemyActionTick.Stop()
-			return replay
+		if lane, err = parseLane(field); err != nil {
+			return 0, LaneLeft, nil, err
+		}
+		laneGiven = true
+	}
+	if tile != nil {
+		if laneGiven && tile.lane() != lane {
+			return 0, LaneLeft, nil, fmt.Errorf("tile %s is in the %s lane, not the %s lane", tile, tile.lane(), lane)
 		}
+		lane = tile.lane()
 	}
+	return choice, lane, tile, nil
+}
+
+// levelDamage returns a card's base damage scaled by its level
+func levelDamage(card clash.Card, stats CardStats) int {
+	return stats.BaseDamage + (card.Level-1)*10
 }
 
 // calculateDamage calculates the card's damage with crit chance for both card and tower
-func calculateDamage(card clash.Card, stats CardStats, targetTowers []Tower) (int, bool, bool) {
-	damage := stats.BaseDamage + (card.Level-1)*10
-	randomFactor := rand.Intn(21) - 10
+func calculateDamage(rng *rand.Rand, card clash.Card, stats CardStats, targetTowers []Tower) (int, bool, bool) {
+	damage := levelDamage(card, stats)
+	randomFactor := rng.Intn(21) - 10
 
 	// Check card crit
-	cardCrit := rand.Float64() < stats.CritChance
+	cardCrit := rng.Float64() < stats.CritChance
 	towerCrit := false
 	critMultiplier := 1.0
 
@@ -539,7 +707,7 @@ func calculateDamage(card clash.Card, stats CardStats, targetTowers []Tower) (in
 			break
 		}
 	}
-	if rand.Float64() < towerCritChance {
+	if rng.Float64() < towerCritChance {
 		towerCrit = true
 	}
 
@@ -555,63 +723,12 @@ func calculateDamage(card clash.Card, stats CardStats, targetTowers []Tower) (in
 	return max(1, totalDamage), cardCrit, towerCrit
 }
 
-// applyDamage applies damage to the appropriate tower in order: Guard Tower 1, Guard Tower 2, King Tower
-func applyDamage(towers *[]Tower, damage int) string {
-	// Define the order of towers: Guard Tower 1, Guard Tower 2, King Tower
-	targetOrder := []string{"Guard Tower 1", "Guard Tower 2", "King Tower"}
-	for _, targetType := range targetOrder {
-		for i, tower := range *towers {
-			if tower.Type == targetType && tower.HP > 0 {
-				(*towers)[i].HP -= damage
-				if (*towers)[i].HP < 0 {
-					(*towers)[i].HP = 0
-				}
-				return fmt.Sprintf("%s (HP now %d)", tower.Type, (*towers)[i].HP)
-			}
-		}
+// towerStatus flags towers that are not shooting yet
+func towerStatus(tower Tower) string {
+	if tower.HP > 0 && !tower.Active {
+		return " (inactive)"
 	}
-	return "No towers left"
-}
-
-// isKingTowerDestroyed checks if the King Tower is destroyed
-func isKingTowerDestroyed(towers []Tower) bool {
-	for _, tower := range towers {
-		if tower.Type == "King Tower" && tower.HP <= 0 {
-			return true
-		}
-	}
-	return false
-}
-
-// displayGameState prints the current state of towers and elixir
-func displayGameState(state GameState) {
-	fmt.Println("\n--- Game State ---")
-	fmt.Printf("Your Elixir: %.1f | Opponent Elixir: %.1f\n", state.PlayerElixir, state.EnemyElixir)
-	fmt.Println("Your Towers:")
-	for _, tower := range state.PlayerTowers {
-		fmt.Printf("  %s: %d/%d HP\n", tower.Type, max(0, tower.HP), tower.MaxHP)
-	}
-	fmt.Println("Opponent Towers:")
-	for _, tower := range state.EnemyTowers {
-		fmt.Printf("  %s: %d/%d HP\n", tower.Type, max(0, tower.HP), tower.MaxHP)
-	}
-	fmt.Println("-----------------")
-}
-
-// simulateEnemyTurn simulates the opponent's turn
-// Connects to players.go: Uses clash.Card from enemyDeck
-func simulateEnemyTurn(deck []clash.Card, enemyElixir float64, opponentName string, targetTowers []Tower) (int, bool, bool, string) {
-	if enemyElixir < 3 || len(deck) == 0 {
-		return 0, false, false, fmt.Sprintf("%s skipped turn (not enough elixir)", opponentName)
-	}
-	card := deck[rand.Intn(len(deck))]
-	stats, exists := cardDatabase[card.Name]
-	if !exists {
-		stats = CardStats{BaseDamage: 50, CritChance: 0.05}
-	}
-	damage, cardCrit, towerCrit := calculateDamage(card, stats, targetTowers)
-	action := fmt.Sprintf("%s used %s (Level %d)", opponentName, card.Name, card.Level)
-	return damage, cardCrit, towerCrit, action
+	return ""
 }
 
 // parseInt converts string to int
@@ -662,4 +779,4 @@ func (l *Logger) Error(format string, v ...interface{}) {
 	if l.errorLog != nil {
 		l.errorLog.Printf(format, v...)
 	}
-}
\ No newline at end of file
+}
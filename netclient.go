@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	// Connects to players.go: The client joins with the clash.Player's tag and deck
+	"github.com/fiskie/go-clash/clash"
+)
+
+// Client side reconnect policy
+const (
+	reconnectAttempts = 5
+	reconnectBackoff  = time.Second // Multiplied by the attempt number
+	staleAfter        = 2 * time.Second
+)
+
+// matchConn is a client's connection to a match server
+type matchConn struct {
+	conn     net.Conn
+	encoder  *json.Encoder
+	messages chan netMessage
+	errs     chan error
+}
+
+// dialMatch connects to a match server and says hello with the player's tag and deck
+func dialMatch(addr string, player clash.Player) (*matchConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, helloTimeout)
+	if err != nil {
+		return nil, err
+	}
+	mc := &matchConn{
+		conn:     conn,
+		encoder:  json.NewEncoder(conn),
+		messages: make(chan netMessage), // Unbuffered so a drop is only seen after every message before it
+		errs:     make(chan error, 1),
+	}
+	hello := netMessage{Type: "hello", Tag: player.Tag, Name: player.Name, Trophies: player.Trophies, Deck: player.CurrentDeck}
+	if err := mc.send(hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Read until the connection drops, then report why
+	go func() {
+		decoder := json.NewDecoder(conn)
+		for {
+			var msg netMessage
+			if err := decoder.Decode(&msg); err != nil {
+				mc.errs <- err
+				return
+			}
+			mc.messages <- msg
+		}
+	}()
+	return mc, nil
+}
+
+// send writes a message to the server
+func (mc *matchConn) send(msg netMessage) error {
+	mc.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return mc.encoder.Encode(msg)
+}
+
+// redial reconnects to the server with growing pauses between attempts
+func redial(addr string, player clash.Player) (*matchConn, error) {
+	var err error
+	for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+		fmt.Printf("Connection lost. Reconnecting (attempt %d of %d)...\n", attempt, reconnectAttempts)
+		time.Sleep(time.Duration(attempt) * reconnectBackoff)
+		var mc *matchConn
+		if mc, err = dialMatch(addr, player); err == nil {
+			return mc, nil
+		}
+	}
+	return nil, err
+}
+
+// playOnline plays a match hosted by a match server. The server runs the match; the
+// client shows the state it receives and sends the cards the player picks.
+// Connects to players.go: Uses clash.Player, clash.Card
+func playOnline(scanner *LineReader, addr string, player clash.Player, logger *Logger) (ReplayData, error) {
+	replay := ReplayData{Actions: []string{}}
+	mc, err := dialMatch(addr, player)
+	if err != nil {
+		return replay, err
+	}
+	defer func() { mc.conn.Close() }()
+	fmt.Printf("Connected to %s.\n", addr)
+
+	rules, _ := lookupRuleset(defaultRuleset)
+	var snap NetSnapshot
+	var opponentName string
+	started := false
+	lastUpdate := time.Now()
+
+	// The match screen opens once the opponent is in
+	var ui *TUI
+	var keys <-chan string
+	defer func() {
+		if ui != nil {
+			ui.Close()
+		}
+	}()
+	displayTick := time.NewTicker(frameInterval)
+	defer displayTick.Stop()
+
+	for {
+		select {
+		case msg := <-mc.messages:
+			switch msg.Type {
+			case "welcome":
+				if msg.Rules != nil {
+					rules = *msg.Rules
+				}
+			case "wait":
+				fmt.Println("Waiting for an opponent to join...")
+			case "start":
+				opponentName = msg.Opponent
+				if !started {
+					started = true
+					ui = newTUI(scanner)
+					keys = ui.Keys()
+				}
+				ui.Say("Playing against %s", opponentName)
+			case "state":
+				if msg.Diff != nil {
+					snap.apply(*msg.Diff)
+				}
+				replay.Actions = append(replay.Actions, msg.Events...)
+				lastUpdate = time.Now()
+				if ui != nil {
+					ui.Log(msg.Events...)
+				}
+			case "played":
+				replay.Plays = append(replay.Plays, CardPlay{Side: SidePlayer, Card: clash.Card{Name: msg.Card}, Lane: msg.Lane, Tile: msg.Tile, At: time.Duration(msg.Tick) * tickInterval})
+			case "error":
+				if !started {
+					return replay, fmt.Errorf("server refused to seat us: %s", msg.Error)
+				}
+				if ui != nil {
+					ui.Say("Server: %s.", msg.Error)
+				}
+			case "end":
+				if ui != nil {
+					ui.Close()
+				}
+				fmt.Printf("\nMatch over! %s.\n", msg.Reason)
+				replay.Actions = append(replay.Actions, msg.Reason)
+				if msg.Result != nil {
+					replay.Result = *msg.Result
+				}
+				// The server's replay can be played again; what this client saw is kept as its log
+				if msg.Replay != nil {
+					actions := replay.Actions
+					replay = *msg.Replay
+					replay.Actions = actions
+				}
+				return replay, nil
+			}
+
+		case err := <-mc.errs:
+			if ui != nil {
+				ui.Close()
+				ui, keys = nil, nil
+			}
+			logger.Error("Lost connection to %s: %v", addr, err)
+			mc.conn.Close()
+			if mc, err = redial(addr, player); err != nil {
+				return replay, fmt.Errorf("could not reconnect to %s: %v", addr, err)
+			}
+			fmt.Println("Reconnected.")
+			started = false
+
+		case input := <-keys:
+			cmd, ok, err := ui.Command(input, len(snap.Hand))
+			if err != nil {
+				ui.Say("Invalid choice: %v.", err)
+			}
+			if !ok {
+				continue
+			}
+			if cmd.Surrender {
+				mc.send(netMessage{Type: "surrender"})
+				continue
+			}
+			// The server matches the play to its hand by name and the tick tells it how old our view is
+			play := netMessage{Type: "play", Card: snap.Hand[cmd.Move.Slot].Name, Lane: cmd.Move.Lane, Tile: cmd.Move.Tile, Tick: snap.Tick}
+			if err := mc.send(play); err != nil {
+				ui.Say("Can't send that play: %v.", err)
+			}
+
+		case <-displayTick.C:
+			if ui == nil {
+				continue
+			}
+			state := snap.gameState(rules, player.Name, opponentName)
+			if behind := time.Since(lastUpdate); behind > staleAfter {
+				ui.Say("Waiting for the server (no update for %s)", behind.Round(time.Second))
+			}
+			ui.Render(&state)
+		}
+	}
+}
+
+// onlineMatch asks whether to host or join a networked match and plays it. The host runs
+// the match server and joins it like any other client.
+func onlineMatch(scanner *LineReader, player clash.Player, rules Ruleset, logger *Logger) (ReplayData, error) {
+	fmt.Println("1. Host a match")
+	fmt.Println("2. Join a match")
+	fmt.Print("Enter number: ")
+	scanner.Scan()
+	host := strings.TrimSpace(scanner.Text()) == "1"
+
+	fmt.Printf("Enter address (default %s): ", defaultMatchAddr)
+	scanner.Scan()
+	addr := strings.TrimSpace(scanner.Text())
+	if addr == "" {
+		addr = defaultMatchAddr
+	}
+
+	if host {
+		server, err := listenMatch(addr, rules, logger)
+		if err != nil {
+			return ReplayData{}, err
+		}
+		fmt.Printf("Hosting a match on %s. Share this address with your opponent.\n", server.Addr())
+		go server.Run()
+		addr = server.Addr()
+	}
+	return playOnline(scanner, addr, player, logger)
+}
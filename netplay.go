@@ -0,0 +1,267 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	// Connects to players.go: Players join with their clash.Card deck and get a clash.Battle result
+	"github.com/fiskie/go-clash/clash"
+)
+
+// netMessage is the single message type exchanged between the match server and its
+// clients, sent as one JSON object per line. Type says which fields are set:
+//
+//	hello     client -> server  Tag, Name, Trophies, Deck
+//	play      client -> server  Card, Lane, Tile, Tick (the last server tick the client saw)
+//	surrender client -> server
+//	welcome   server -> client  Tag, Rules
+//	wait      server -> client  waiting for the second player
+//	start     server -> client  Opponent
+//	state     server -> client  Diff, Events
+//	played    server -> client  Card, Lane, Tile, Tick (the tick the play was applied on)
+//	error     server -> client  Error
+//	end       server -> client  Result, Reason, Replay (the whole match, as the server saw it)
+type netMessage struct {
+	Type     string        `json:"type"`
+	Tag      string        `json:"tag,omitempty"`
+	Name     string        `json:"name,omitempty"`
+	Trophies int           `json:"trophies,omitempty"`
+	Deck     []clash.Card  `json:"deck,omitempty"`
+	Card     string        `json:"card,omitempty"`
+	Lane     Lane          `json:"lane"`
+	Tile     *Tile         `json:"tile,omitempty"`
+	Tick     int           `json:"tick,omitempty"`
+	Rules    *Ruleset      `json:"rules,omitempty"`
+	Opponent string        `json:"opponent,omitempty"`
+	Diff     *NetDiff      `json:"diff,omitempty"`
+	Events   []string      `json:"events,omitempty"`
+	Result   *clash.Battle `json:"result,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Replay   *ReplayData   `json:"replay,omitempty"`
+}
+
+// NetUnit is a unit as a client sees it
+type NetUnit struct {
+	ID    int        `json:"id"`
+	Card  clash.Card `json:"card"`
+	Side  Side       `json:"side"`
+	Lane  Lane       `json:"lane"`
+	HP    int        `json:"hp"`
+	MaxHP int        `json:"maxHp"`
+	Pos   float64    `json:"pos"`
+}
+
+// NetSnapshot is the match as one player sees it. Every client sees itself as
+// SidePlayer with its back line at zero, whichever side it plays on the server.
+type NetSnapshot struct {
+	Tick        int           `json:"tick"`
+	Elapsed     time.Duration `json:"elapsed"`
+	Elixir      float64       `json:"elixir"`
+	EnemyElixir float64       `json:"enemyElixir"`
+	Hand        []clash.Card  `json:"hand"`
+	Next        clash.Card    `json:"next"`
+	Towers      []Tower       `json:"towers"`
+	EnemyTowers []Tower       `json:"enemyTowers"`
+	Crowns      int           `json:"crowns"`
+	EnemyCrowns int           `json:"enemyCrowns"`
+	Units       []NetUnit     `json:"units"` // Sorted by ID
+}
+
+// NetDiff is the change between two snapshots. Fields that did not change are left out;
+// a full diff carries the whole snapshot and replaces whatever the client had.
+type NetDiff struct {
+	Tick        int           `json:"tick"`
+	Full        bool          `json:"full,omitempty"`
+	Elapsed     time.Duration `json:"elapsed"`
+	Elixir      float64       `json:"elixir"`
+	EnemyElixir float64       `json:"enemyElixir"`
+	Hand        []clash.Card  `json:"hand,omitempty"`
+	Next        *clash.Card   `json:"next,omitempty"`
+	Towers      []Tower       `json:"towers,omitempty"`
+	EnemyTowers []Tower       `json:"enemyTowers,omitempty"`
+	Crowns      *[2]int       `json:"crowns,omitempty"`
+	Units       []NetUnit     `json:"units,omitempty"`   // Units that appeared or changed
+	Removed     []int         `json:"removed,omitempty"` // IDs of units that are gone
+}
+
+// netSnapshot captures the match from one side's point of view
+func netSnapshot(state *GameState, side Side, tick int) NetSnapshot {
+	// The enemy side sees the arena mirrored so its own back line is at zero
+	view := func(pos float64) float64 {
+		if side == SideEnemy {
+			return arenaLength - pos
+		}
+		return pos
+	}
+	viewTowers := func(towers []Tower) []Tower {
+		seen := make([]Tower, len(towers))
+		for i, tower := range towers {
+			seen[i] = tower
+			seen[i].Pos = view(tower.Pos)
+			seen[i].cooldown = 0
+		}
+		return seen
+	}
+
+	snap := NetSnapshot{
+		Tick:        tick,
+		Elapsed:     state.Elapsed,
+		Elixir:      state.elixir(side),
+		EnemyElixir: state.elixir(side.opposite()),
+		Towers:      viewTowers(state.towers(side)),
+		EnemyTowers: viewTowers(state.towers(side.opposite())),
+		Crowns:      state.Crowns.crowns(side),
+		EnemyCrowns: state.Crowns.crowns(side.opposite()),
+	}
+	if cycle := state.cycle(side); cycle != nil {
+		snap.Hand = append([]clash.Card(nil), cycle.Hand...)
+		snap.Next, _ = cycle.Next()
+	}
+	for _, u := range state.Units {
+		if u.HP <= 0 {
+			continue
+		}
+		owner := u.Side
+		if side == SideEnemy {
+			owner = owner.opposite()
+		}
+		snap.Units = append(snap.Units, NetUnit{
+			ID: u.ID, Card: u.Card, Side: owner, Lane: u.Lane, HP: u.HP, MaxHP: u.MaxHP, Pos: view(u.Pos),
+		})
+	}
+	sort.Slice(snap.Units, func(i, j int) bool { return snap.Units[i].ID < snap.Units[j].ID })
+	return snap
+}
+
+// diffSnapshots returns what changed from base to next. Without a base the diff is full.
+func diffSnapshots(base *NetSnapshot, next NetSnapshot) NetDiff {
+	diff := NetDiff{Tick: next.Tick, Elapsed: next.Elapsed, Elixir: next.Elixir, EnemyElixir: next.EnemyElixir}
+	if base == nil {
+		diff.Full = true
+		base = &NetSnapshot{}
+	}
+	if diff.Full || !sameCards(base.Hand, next.Hand) {
+		diff.Hand = next.Hand
+	}
+	if diff.Full || base.Next != next.Next {
+		nextCard := next.Next
+		diff.Next = &nextCard
+	}
+	if diff.Full || !sameTowers(base.Towers, next.Towers) {
+		diff.Towers = next.Towers
+	}
+	if diff.Full || !sameTowers(base.EnemyTowers, next.EnemyTowers) {
+		diff.EnemyTowers = next.EnemyTowers
+	}
+	if diff.Full || base.Crowns != next.Crowns || base.EnemyCrowns != next.EnemyCrowns {
+		diff.Crowns = &[2]int{next.Crowns, next.EnemyCrowns}
+	}
+
+	before := make(map[int]NetUnit, len(base.Units))
+	for _, u := range base.Units {
+		before[u.ID] = u
+	}
+	for _, u := range next.Units {
+		if old, exists := before[u.ID]; !exists || old != u {
+			diff.Units = append(diff.Units, u)
+		}
+		delete(before, u.ID)
+	}
+	for id := range before {
+		diff.Removed = append(diff.Removed, id)
+	}
+	sort.Ints(diff.Removed)
+	return diff
+}
+
+// apply updates a snapshot with a diff received from the server
+func (s *NetSnapshot) apply(diff NetDiff) {
+	if diff.Full {
+		*s = NetSnapshot{}
+	}
+	s.Tick, s.Elapsed, s.Elixir, s.EnemyElixir = diff.Tick, diff.Elapsed, diff.Elixir, diff.EnemyElixir
+	if diff.Hand != nil {
+		s.Hand = diff.Hand
+	}
+	if diff.Next != nil {
+		s.Next = *diff.Next
+	}
+	if diff.Towers != nil {
+		s.Towers = diff.Towers
+	}
+	if diff.EnemyTowers != nil {
+		s.EnemyTowers = diff.EnemyTowers
+	}
+	if diff.Crowns != nil {
+		s.Crowns, s.EnemyCrowns = diff.Crowns[0], diff.Crowns[1]
+	}
+
+	units := make(map[int]NetUnit, len(s.Units)+len(diff.Units))
+	for _, u := range s.Units {
+		units[u.ID] = u
+	}
+	for _, u := range diff.Units {
+		units[u.ID] = u
+	}
+	for _, id := range diff.Removed {
+		delete(units, id)
+	}
+	s.Units = s.Units[:0]
+	for _, u := range units {
+		s.Units = append(s.Units, u)
+	}
+	sort.Slice(s.Units, func(i, j int) bool { return s.Units[i].ID < s.Units[j].ID })
+}
+
+// gameState rebuilds a GameState from a snapshot so it can be displayed like a local match
+func (s *NetSnapshot) gameState(rules Ruleset, name, enemyName string) GameState {
+	state := GameState{
+		PlayerTowers: s.Towers,
+		EnemyTowers:  s.EnemyTowers,
+		PlayerElixir: s.Elixir,
+		EnemyElixir:  s.EnemyElixir,
+		PlayerName:   name,
+		EnemyName:    enemyName,
+		Rules:        rules,
+		Elapsed:      s.Elapsed,
+		Crowns:       CrownTracker{Player: s.Crowns, Enemy: s.EnemyCrowns},
+		PlayerCycle:  &CardCycle{Hand: s.Hand},
+	}
+	if s.Next.Name != "" {
+		state.PlayerCycle.Queue = []clash.Card{s.Next}
+	}
+	for _, u := range s.Units {
+		state.Units = append(state.Units, &Unit{
+			ID: u.ID, Card: u.Card, Stats: lookupCardStats(u.Card.Name), Side: u.Side, Lane: u.Lane,
+			HP: u.HP, MaxHP: u.MaxHP, Pos: u.Pos,
+		})
+	}
+	return state
+}
+
+// sameCards reports whether two hands hold the same cards in the same slots
+func sameCards(a, b []clash.Card) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTowers reports whether two tower lists look the same to a client
+func sameTowers(a, b []Tower) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].HP != b[i].HP || a[i].Active != b[i].Active {
+			return false
+		}
+	}
+	return true
+}
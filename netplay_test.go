@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetSnapshot(t *testing.T) {
+	deck := []clash.Card{{Name: "A"}, {Name: "B"}, {Name: "C"}, {Name: "D"}, {Name: "E"}}
+	state := GameState{
+		PlayerTowers: newTowers(SidePlayer),
+		EnemyTowers:  newTowers(SideEnemy),
+		PlayerElixir: 4,
+		EnemyElixir:  7,
+		EnemyCycle:   dealCardCycle(deck),
+		Units: []*Unit{
+			{ID: 2, Side: SidePlayer, Lane: LaneLeft, HP: 100, Pos: 10},
+			{ID: 1, Side: SideEnemy, Lane: LaneRight, HP: 100, Pos: 20},
+			{ID: 3, Side: SideEnemy, Lane: LaneRight, HP: 0, Pos: 20},
+		},
+	}
+	state.EnemyTowers[0].HP = 0
+	state.Crowns.update(&state)
+
+	// The enemy sees the arena from its own end
+	snap := netSnapshot(&state, SideEnemy, 5)
+	assert.Equal(t, 5, snap.Tick)
+	assert.Equal(t, 7.0, snap.Elixir)
+	assert.Equal(t, 4.0, snap.EnemyElixir)
+	assert.Equal(t, deck[:handSize], snap.Hand)
+	assert.Equal(t, deck[handSize], snap.Next)
+	assert.Equal(t, 0, snap.Towers[0].HP)
+	assert.Equal(t, arenaLength-state.EnemyTowers[0].Pos, snap.Towers[0].Pos)
+	assert.Equal(t, 0, snap.Crowns)
+	assert.Equal(t, 1, snap.EnemyCrowns)
+	assert.Equal(t, []NetUnit{
+		{ID: 1, Side: SidePlayer, Lane: LaneRight, HP: 100, Pos: arenaLength - 20},
+		{ID: 2, Side: SideEnemy, Lane: LaneLeft, HP: 100, Pos: arenaLength - 10},
+	}, snap.Units)
+
+	// The player's view is the server's
+	snap = netSnapshot(&state, SidePlayer, 5)
+	assert.Empty(t, snap.Hand)
+	assert.Equal(t, 10.0, snap.Units[1].Pos)
+	assert.Equal(t, SidePlayer, snap.Units[1].Side)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	towers := newTowers(SidePlayer)
+	base := NetSnapshot{
+		Tick:        1,
+		Elixir:      5,
+		Hand:        []clash.Card{{Name: "A"}, {Name: "B"}, {Name: "C"}, {Name: "D"}},
+		Next:        clash.Card{Name: "E"},
+		Towers:      towers,
+		EnemyTowers: newTowers(SideEnemy),
+		Units: []NetUnit{
+			{ID: 1, HP: 100, Pos: 5},
+			{ID: 2, HP: 100, Pos: 6},
+		},
+	}
+	for _, tt := range []struct {
+		name   string
+		change func(s *NetSnapshot)
+		check  func(t *testing.T, diff NetDiff)
+	}{
+		{"nothing but the clock", func(s *NetSnapshot) { s.Elixir = 5.3 }, func(t *testing.T, diff NetDiff) {
+			assert.False(t, diff.Full)
+			assert.Nil(t, diff.Hand)
+			assert.Nil(t, diff.Next)
+			assert.Nil(t, diff.Towers)
+			assert.Nil(t, diff.Crowns)
+			assert.Empty(t, diff.Units)
+			assert.Empty(t, diff.Removed)
+		}},
+		{"card played", func(s *NetSnapshot) {
+			s.Hand = []clash.Card{{Name: "A"}, {Name: "E"}, {Name: "C"}, {Name: "D"}}
+			s.Next = clash.Card{Name: "F"}
+		}, func(t *testing.T, diff NetDiff) {
+			assert.Len(t, diff.Hand, handSize)
+			assert.Equal(t, "F", diff.Next.Name)
+		}},
+		{"units moved, spawned and died", func(s *NetSnapshot) {
+			s.Units = []NetUnit{{ID: 2, HP: 80, Pos: 7}, {ID: 3, HP: 100, Pos: 1}}
+		}, func(t *testing.T, diff NetDiff) {
+			assert.Equal(t, []NetUnit{{ID: 2, HP: 80, Pos: 7}, {ID: 3, HP: 100, Pos: 1}}, diff.Units)
+			assert.Equal(t, []int{1}, diff.Removed)
+		}},
+		{"tower taken", func(s *NetSnapshot) {
+			s.Towers = newTowers(SidePlayer)
+			s.Towers[1].HP = 0
+			s.EnemyCrowns = 1
+		}, func(t *testing.T, diff NetDiff) {
+			assert.Len(t, diff.Towers, 3)
+			assert.Nil(t, diff.EnemyTowers)
+			assert.Equal(t, &[2]int{0, 1}, diff.Crowns)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base
+			next.Tick, next.Units = 2, append([]NetUnit(nil), base.Units...)
+			tt.change(&next)
+			diff := diffSnapshots(&base, next)
+			assert.Equal(t, 2, diff.Tick)
+			tt.check(t, diff)
+
+			// Applying the diff to the base gives the next snapshot
+			seen := base
+			seen.Units = append([]NetUnit(nil), base.Units...)
+			seen.apply(diff)
+			assert.Equal(t, next, seen)
+
+			// So does applying a full diff to anything
+			seen = NetSnapshot{Tick: 9, Units: []NetUnit{{ID: 7}}}
+			full := diffSnapshots(nil, next)
+			assert.True(t, full.Full)
+			seen.apply(full)
+			assert.Equal(t, next, seen)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	// Connects to players.go: Profiles are keyed by clash.Player tag and record clash.Battle results
+	"github.com/fiskie/go-clash/clash"
+)
+
+// historySize is how many matches are kept per player; older ones are dropped
+const historySize = 200
+
+// MatchRecord is one simulated match in a player's history
+type MatchRecord struct {
+	Time           time.Time `json:"time"`
+	Mode           string    `json:"mode"`
+	Opponent       string    `json:"opponent"`
+	OpponentTag    string    `json:"opponentTag,omitempty"`
+	Result         string    `json:"result"` // "win", "loss" or "draw"
+	Crowns         int       `json:"crowns"`
+	OpponentCrowns int       `json:"opponentCrowns"`
+	TrophyChange   int       `json:"trophyChange"`
+	Trophies       int       `json:"trophies"` // Local trophies after the match
+	Replay         string    `json:"replay,omitempty"`
+}
+
+// Profile is what the simulator remembers about a player between runs
+type Profile struct {
+	Tag          string            `json:"tag"`
+	Name         string            `json:"name"`
+	Trophies     int               `json:"trophies"` // Local trophies, starting from the player's real count
+	BestTrophies int               `json:"bestTrophies"`
+	Arena        clash.Arena       `json:"arena"`
+	League       clash.LeagueStats `json:"leagueStatistics"`
+	Wins         int               `json:"wins"`
+	Losses       int               `json:"losses"`
+	Draws        int               `json:"draws"`
+	History      []MatchRecord     `json:"history"` // Oldest first
+}
+
+// ProfileStore keeps every local profile in one JSON file
+type ProfileStore struct {
+	path     string
+	Profiles map[string]*Profile `json:"profiles"` // By normalised tag
+}
+
+// profilePath returns the file profiles are stored in
+func profilePath() string {
+	return filepath.Join(goclashDir(), "profiles.json")
+}
+
+// loadProfiles reads the profile store. A missing file is an empty store.
+func loadProfiles(path string) (*ProfileStore, error) {
+	store := &ProfileStore{path: path, Profiles: map[string]*Profile{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	if store.Profiles == nil {
+		store.Profiles = map[string]*Profile{}
+	}
+	return store, nil
+}
+
+// Save writes the store. The file is replaced in one step so a crash never leaves half of it.
+func (s *ProfileStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Profile returns the local profile of a player, creating it with the player's real
+// trophy count the first time they play
+func (s *ProfileStore) Profile(player clash.Player) *Profile {
+	tag := clash.NormaliseTag(player.Tag)
+	profile, exists := s.Profiles[tag]
+	if !exists {
+		profile = &Profile{Tag: tag, Trophies: player.Trophies, BestTrophies: player.Trophies, League: player.LeagueStatistics}
+		s.Profiles[tag] = profile
+	}
+	profile.Name = player.Name
+	profile.Arena = arenaFor(profile.Trophies).Arena
+	profile.rollSeason(time.Now())
+	return profile
+}
+
+// rollSeason starts a new season when the month has changed. The season that ended
+// becomes the previous season, the best season is kept, and trophies above the
+// reset floor are halved like at the end of a real season.
+func (p *Profile) rollSeason(now time.Time) {
+	id := seasonID(now)
+	current := &p.League.CurrentSeason
+	if current.ID == id {
+		return
+	}
+	if current.ID != "" {
+		ended := *current
+		ended.Trophies = p.Trophies
+		p.League.PreviousSeason = ended
+		if ended.BestTrophies > p.League.BestSeason.BestTrophies || p.League.BestSeason.ID == "" {
+			p.League.BestSeason = ended
+		}
+		if p.Trophies > seasonResetFloor {
+			p.Trophies = seasonResetFloor + (p.Trophies-seasonResetFloor)/2
+		}
+	}
+	*current = clash.Season{ID: id, Trophies: p.Trophies, BestTrophies: p.Trophies}
+}
+
+// trophyModes are the modes played for ladder trophies. The others have opponents
+// whose trophies mean nothing on the ladder, like a tournament score or a war deck, so
+// they are only kept as history.
+var trophyModes = map[string]bool{"normal": true, "ranked": true}
+
+// Record adds a finished match to the profile and, in a trophy mode, settles its
+// trophies. The trophy changes are written into the battle's players like the battle
+// log reports them.
+func (p *Profile) Record(battle *clash.Battle, mode, replay string) MatchRecord {
+	team, opponent := &battle.Team[0], &battle.Opponent[0]
+	record := MatchRecord{
+		Time:           time.Now(),
+		Mode:           mode,
+		Opponent:       opponent.Name,
+		OpponentTag:    opponent.Tag,
+		Crowns:         team.Crowns,
+		OpponentCrowns: opponent.Crowns,
+		Replay:         replay,
+	}
+
+	p.rollSeason(record.Time)
+	switch outcome := battle.Outcome(); {
+	case outcome.IsDraw:
+		record.Result = "draw"
+		p.Draws++
+	case team.Crowns > opponent.Crowns:
+		record.Result = "win"
+		p.Wins++
+	default:
+		record.Result = "loss"
+		p.Losses++
+	}
+	team.StartingTrophies = p.Trophies
+	if trophyModes[mode] {
+		// The arena gate keeps a loss from dropping the player out of their arena
+		change := ladderTrophyChange(p.Trophies, opponent.StartingTrophies, team.Crowns, opponent.Crowns)
+		record.TrophyChange = max(change, arenaFor(p.Trophies).Trophies-p.Trophies)
+		team.TrophyChange = record.TrophyChange
+		opponent.TrophyChange = -change
+	}
+
+	p.Trophies += record.TrophyChange
+	p.BestTrophies = max(p.BestTrophies, p.Trophies)
+	p.Arena = arenaFor(p.Trophies).Arena
+	season := &p.League.CurrentSeason
+	season.Trophies = p.Trophies
+	season.BestTrophies = max(season.BestTrophies, p.Trophies)
+	record.Trophies = p.Trophies
+	p.History = append(p.History, record)
+	if len(p.History) > historySize {
+		p.History = p.History[len(p.History)-historySize:]
+	}
+	return record
+}
+
+// showHistory prints a player's record and their most recent matches
+func showHistory(profile *Profile, count int) {
+	fmt.Printf("\n%s (%s): %d trophies (best %d) in %s, %d wins, %d losses, %d draws\n",
+		profile.Name, profile.Tag, profile.Trophies, profile.BestTrophies, profile.Arena.Name, profile.Wins, profile.Losses, profile.Draws)
+	for _, season := range []struct {
+		label string
+		clash.Season
+	}{
+		{"This season", profile.League.CurrentSeason},
+		{"Previous season", profile.League.PreviousSeason},
+		{"Best season", profile.League.BestSeason},
+	} {
+		if season.ID != "" {
+			fmt.Printf("%s (%s): %d trophies, best %d\n", season.label, season.ID, season.Trophies, season.BestTrophies)
+		}
+	}
+	if len(profile.History) == 0 {
+		fmt.Println("No matches played yet.")
+		return
+	}
+	fmt.Println("\nRecent matches:")
+	for i := len(profile.History) - 1; i >= 0 && i >= len(profile.History)-count; i-- {
+		m := profile.History[i]
+		fmt.Printf("%s  %-10s %-4s %d-%d vs %-16s %+4d  %d trophies\n", m.Time.Format("2006-01-02 15:04"),
+			m.Mode, m.Result, m.Crowns, m.OpponentCrowns, m.Opponent, m.TrophyChange, m.Trophies)
+	}
+}
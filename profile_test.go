@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile_Record(t *testing.T) {
+	battle := func(crowns, opponentCrowns, opponentTrophies int) *clash.Battle {
+		return &clash.Battle{
+			Team:     []clash.BattlePlayer{{Tag: "#2PP", Crowns: crowns}},
+			Opponent: []clash.BattlePlayer{{Tag: "#9LL", Crowns: opponentCrowns, StartingTrophies: opponentTrophies}},
+		}
+	}
+
+	for _, tt := range []struct {
+		mode     string
+		battle   *clash.Battle
+		result   string
+		trophies int
+	}{
+		{"ranked", battle(1, 0, 5200), "win", 5230},
+		{"normal", battle(0, 3, 5200), "loss", 5170},
+		// Tournament scores and war decks have no trophies to play against
+		{"tournament", battle(0, 1, 12), "loss", 5200},
+		{"clan war", battle(0, 1, 0), "loss", 5200},
+		{"river race", battle(2, 1, 0), "win", 5200},
+		{"online", battle(0, 1, 0), "loss", 5200},
+	} {
+		profile := &Profile{Tag: "#2PP", Trophies: 5200}
+		match := profile.Record(tt.battle, tt.mode, "")
+		assert.Equal(t, tt.result, match.Result, tt.mode)
+		assert.Equal(t, tt.trophies, profile.Trophies, tt.mode)
+		assert.Equal(t, tt.trophies-5200, match.TrophyChange, tt.mode)
+		assert.Len(t, profile.History, 1, tt.mode)
+	}
+}
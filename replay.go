@@ -0,0 +1,413 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	// Connects to players.go: Replays keep both decks as clash.Card and the result as clash.Battle
+	"github.com/fiskie/go-clash/clash"
+)
+
+// replayFormatVersion is bumped whenever the replay file format changes
+const replayFormatVersion = 1
+
+// ReplayData is a recorded match. Together with the seed, the dealt openings and the
+// timestamped commands are enough to simulate the match again tick for tick.
+type ReplayData struct {
+	Version   int          `json:"version"`
+	Seed      int64        `json:"seed"`
+	Rules     RulesetRef   `json:"ruleset"`
+	Player    ReplayPlayer `json:"player"`
+	Opponent  ReplayPlayer `json:"opponent"`
+	Plays     []CardPlay   `json:"commands"` // Every card played, by both sides, in order
+	Surrender *Surrender   `json:"surrender,omitempty"`
+	Final     ReplayFinal  `json:"final"`
+	Result    clash.Battle `json:"result"` // Final result, in the same shape as the battle log
+	Actions   []string     `json:"log"`    // What happened, as shown during the match
+}
+
+// RulesetRef names the ruleset and ruleset version a match was played with
+type RulesetRef struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+// ReplayPlayer is one side of a recorded match
+type ReplayPlayer struct {
+	Tag      string       `json:"tag"`
+	Name     string       `json:"name"`
+	Trophies int          `json:"trophies"`
+	Deck     []clash.Card `json:"deck"`    // The deck as it was brought, unshuffled, with card levels
+	Opening  []clash.Card `json:"opening"` // The deck in the order it was dealt
+}
+
+// Surrender records a side giving up, or leaving an online match for good
+type Surrender struct {
+	Side Side          `json:"side"`
+	At   time.Duration `json:"at"`
+	Left bool          `json:"left,omitempty"`
+}
+
+// ReplayFinal is the state a match ended in, checked when a replay is simulated again
+type ReplayFinal struct {
+	Elapsed      time.Duration `json:"elapsed"`
+	PlayerCrowns int           `json:"playerCrowns"`
+	EnemyCrowns  int           `json:"enemyCrowns"`
+	PlayerTowers []int         `json:"playerTowers"` // Tower HP, in newTowers order
+	EnemyTowers  []int         `json:"enemyTowers"`
+	Winner       string        `json:"winner"` // "player", "opponent" or "draw"
+	Reason       string        `json:"reason"`
+}
+
+// ref returns the name and version of a ruleset
+func (r Ruleset) ref() RulesetRef {
+	return RulesetRef{Name: r.Name, Version: r.Version}
+}
+
+// surrenderEnd is how a match ends when a side gives up or leaves
+func surrenderEnd(side Side, left bool) MatchEnd {
+	if left {
+		return MatchEnd{Over: true, Winner: side.opposite(), Reason: "won because the opponent left"}
+	}
+	return MatchEnd{Over: true, Winner: side.opposite(), Reason: "won because the opponent surrendered"}
+}
+
+// replayFinal summarises the state a match ended in
+func replayFinal(state *GameState, end MatchEnd) ReplayFinal {
+	hp := func(towers []Tower) []int {
+		values := make([]int, len(towers))
+		for i, tower := range towers {
+			values[i] = max(0, tower.HP)
+		}
+		return values
+	}
+	winner := "draw"
+	if !end.Draw {
+		winner = "player"
+		if end.Winner == SideEnemy {
+			winner = "opponent"
+		}
+	}
+	return ReplayFinal{
+		Elapsed:      state.Elapsed,
+		PlayerCrowns: state.Crowns.Player,
+		EnemyCrowns:  state.Crowns.Enemy,
+		PlayerTowers: hp(state.PlayerTowers),
+		EnemyTowers:  hp(state.EnemyTowers),
+		Winner:       winner,
+		Reason:       end.Reason,
+	}
+}
+
+// newReplay starts the replay of a match that was just set up, with both decks and the
+// order they were dealt in
+func newReplay(state *GameState, player clash.Player, opponent clash.PlayerRef, enemyDeck []clash.Card) ReplayData {
+	return ReplayData{
+		Version: replayFormatVersion,
+		Seed:    state.Seed,
+		Rules:   state.Rules.ref(),
+		Player: ReplayPlayer{Tag: player.Tag, Name: state.PlayerName, Trophies: player.Trophies,
+			Deck: player.CurrentDeck, Opening: state.PlayerCycle.Order()},
+		Opponent: ReplayPlayer{Tag: opponent.Tag, Name: opponent.Name, Trophies: opponent.Trophies,
+			Deck: enemyDeck, Opening: state.EnemyCycle.Order()},
+		Actions: []string{},
+	}
+}
+
+// finish records the commands of a match, the state it ended in and its result as the
+// player's battle log would show it
+func (r *ReplayData) finish(state *GameState, end MatchEnd, player clash.Player) {
+	r.Plays = state.Plays
+	r.Final = replayFinal(state, end)
+	r.Result = battleResult(state, player.Arena,
+		clash.BattlePlayer{Tag: player.Tag, Name: player.Name, StartingTrophies: r.Player.Trophies, Cards: r.Player.Deck},
+		clash.BattlePlayer{Tag: r.Opponent.Tag, Name: r.Opponent.Name, StartingTrophies: r.Opponent.Trophies, Cards: r.Opponent.Deck},
+	)
+}
+
+// simulateReplay plays a recorded match again from its seed and commands. step is called
+// after every tick with the events of that tick and can stop the simulation by returning
+// false. It returns the final state and how the match ended.
+func simulateReplay(r *ReplayData, step func(state *GameState, events []string) bool) (*GameState, MatchEnd, error) {
+	if r.Version != replayFormatVersion {
+		return nil, MatchEnd{}, fmt.Errorf("unsupported replay version %d (this build reads version %d)", r.Version, replayFormatVersion)
+	}
+	rules, err := lookupRuleset(r.Rules.Name)
+	if err != nil {
+		return nil, MatchEnd{}, err
+	}
+	if rules.Version != r.Rules.Version {
+		return nil, MatchEnd{}, fmt.Errorf("replay was recorded with %s version %d, this build has version %d",
+			rules.Name, r.Rules.Version, rules.Version)
+	}
+
+	match := newGameState(rules, r.Seed, r.Player.Name, r.Opponent.Name, r.Player.Deck, r.Opponent.Deck)
+	state := &match
+	// Opponents can bring their own cycle, so deal the recorded openings
+	if len(r.Player.Opening) > 0 {
+		state.PlayerCycle = dealCardCycle(r.Player.Opening)
+	}
+	if len(r.Opponent.Opening) > 0 {
+		state.EnemyCycle = dealCardCycle(r.Opponent.Opening)
+	}
+
+	next := 0
+	for {
+		// Commands are applied before the tick they were made on, like during the match
+		var events []string
+		for next < len(r.Plays) && r.Plays[next].At <= state.Elapsed {
+			play := r.Plays[next]
+			next++
+			slot := state.cycle(play.Side).Slot(play.Card.Name)
+			action, err := playCard(state, play.Side, Move{Slot: slot, Lane: play.Lane, Tile: play.Tile})
+			if err != nil {
+				return state, MatchEnd{}, fmt.Errorf("command %d at %s (%s plays %s) failed: %v",
+					next, matchClock(play.At), state.sideName(play.Side), play.Card.Name, err)
+			}
+			events = append(events, action)
+		}
+		if r.Surrender != nil && r.Surrender.At <= state.Elapsed {
+			state.Crowns.surrender(r.Surrender.Side)
+			end := surrenderEnd(r.Surrender.Side, r.Surrender.Left)
+			events = append(events, fmt.Sprintf("%s %s", state.sideName(end.Winner), end.Reason))
+			if step != nil {
+				step(state, events)
+			}
+			return state, end, nil
+		}
+
+		events = append(events, advanceMatch(state, tickInterval)...)
+		end, ended := checkMatchEnd(state)
+		events = append(events, ended...)
+		if step != nil && !step(state, events) {
+			return state, end, nil
+		}
+		if end.Over {
+			return state, end, nil
+		}
+		if state.Elapsed > rules.matchLength()+tickInterval {
+			return state, end, fmt.Errorf("replay ran past the end of the match without a result")
+		}
+	}
+}
+
+// verifyReplay simulates a replay again and lists every way its final state differs
+// from the recorded one. An empty list means the replay is reproduced exactly.
+func verifyReplay(r *ReplayData) (ReplayFinal, []string, error) {
+	state, end, err := simulateReplay(r, nil)
+	if err != nil {
+		return ReplayFinal{}, nil, err
+	}
+	got, want := replayFinal(state, end), r.Final
+	var diffs []string
+	check := func(field string, got, want interface{}) {
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			diffs = append(diffs, fmt.Sprintf("%s: recorded %v, simulated %v", field, want, got))
+		}
+	}
+	check("match time", matchClock(got.Elapsed), matchClock(want.Elapsed))
+	check("player crowns", got.PlayerCrowns, want.PlayerCrowns)
+	check("opponent crowns", got.EnemyCrowns, want.EnemyCrowns)
+	check("player tower HP", got.PlayerTowers, want.PlayerTowers)
+	check("opponent tower HP", got.EnemyTowers, want.EnemyTowers)
+	check("winner", got.Winner, want.Winner)
+	check("reason", got.Reason, want.Reason)
+	return got, diffs, nil
+}
+
+// goclashDir returns the directory GoClash keeps its files in
+func goclashDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, "goclash")
+}
+
+// replayDir returns the directory replays are saved in
+func replayDir() string {
+	return filepath.Join(goclashDir(), "replays")
+}
+
+// saveReplay writes a replay to the replay directory and returns its path
+func saveReplay(r *ReplayData) (string, error) {
+	if err := os.MkdirAll(replayDir(), 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// Matches finished in the same second get a number instead of replacing each other
+	name := fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), strings.TrimPrefix(r.Player.Tag, "#"))
+	path := filepath.Join(replayDir(), name+".json")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(replayDir(), fmt.Sprintf("%s-%d.json", name, n))
+	}
+	return path, ioutil.WriteFile(path, data, 0644)
+}
+
+// loadReplay reads a replay file
+func loadReplay(path string) (*ReplayData, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r ReplayData
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing replay %s: %v", path, err)
+	}
+	return &r, nil
+}
+
+// listReplays returns the saved replay files, newest first
+func listReplays() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(replayDir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	return files, nil
+}
+
+// viewReplay steps through a replay in the match view. Every key shows the next
+// event, f skips ahead ten seconds and q stops.
+func viewReplay(scanner *LineReader, r *ReplayData) error {
+	ui := newTUI(scanner)
+	defer ui.Close()
+	ui.help = "Enter/Space/→ next event · f skip 10s · q quit"
+
+	skipUntil := time.Duration(0)
+	_, end, err := simulateReplay(r, func(state *GameState, events []string) bool {
+		ui.Log(events...)
+		if len(events) == 0 || state.Elapsed < skipUntil {
+			return true
+		}
+		ui.Say("%s: %d of %d commands played", matchClock(state.Elapsed), len(state.Plays), len(r.Plays))
+		ui.Render(state)
+		switch <-ui.Keys() {
+		case "q", "ctrl-c", "esc":
+			return false
+		case "f":
+			skipUntil = state.Elapsed + 10*time.Second
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if end.Over {
+		ui.Say("Match over: %s. Press any key.", end.Reason)
+		<-ui.Keys()
+	}
+	return nil
+}
+
+// replayCommand runs "goclash replay [--view] <file>": it verifies that the replay plays
+// out as recorded, or steps through it with --view. It returns the exit code.
+func replayCommand(args []string) int {
+	fs := newFlagSet("replay", "replay [--view] <file>")
+	view := fs.Bool("view", false, "step through the match in the arena view instead of verifying it")
+	args, err := parseFlags(fs, args)
+	if err != nil || len(args) != 1 {
+		return usageError(fs, err)
+	}
+	r, err := loadReplay(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+		return exitError
+	}
+	if *view {
+		if err := viewReplay(newLineReader(os.Stdin), r); err != nil {
+			fmt.Fprintf(os.Stderr, "goclash: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+	return printVerification(r)
+}
+
+// printVerification verifies a replay and prints the outcome. It returns exitOK when the
+// replay reproduces the recorded match and exitError otherwise.
+func printVerification(r *ReplayData) int {
+	fmt.Printf("%s (%s) vs %s (%s), %s v%d, seed %d, %d commands\n", r.Player.Name, r.Player.Tag,
+		r.Opponent.Name, r.Opponent.Tag, r.Rules.Name, r.Rules.Version, r.Seed, len(r.Plays))
+	final, diffs, err := verifyReplay(r)
+	if err != nil {
+		fmt.Printf("Replay failed: %v\n", err)
+		return exitError
+	}
+	if len(diffs) > 0 {
+		fmt.Println("Replay does NOT match the recorded match:")
+		for _, diff := range diffs {
+			fmt.Printf("- %s\n", diff)
+		}
+		return exitError
+	}
+	fmt.Printf("Replay verified: %s wins %d - %d at %s (%s)\n", final.Winner,
+		final.PlayerCrowns, final.EnemyCrowns, matchClock(final.Elapsed), final.Reason)
+	return exitOK
+}
+
+// replayMenu lists the saved replays and verifies or watches the one picked
+func replayMenu(scanner *LineReader) {
+	files, err := listReplays()
+	if err != nil || len(files) == 0 {
+		fmt.Printf("No saved replays in %s.\n", replayDir())
+		return
+	}
+	fmt.Println("\nSaved replays:")
+	for i, file := range files {
+		if i == 10 {
+			fmt.Printf("... and %d older ones\n", len(files)-10)
+			break
+		}
+		fmt.Printf("%d. %s\n", i+1, filepath.Base(file))
+	}
+	fmt.Print("Enter number: ")
+	scanner.Scan()
+	choice, err := parseInt(strings.TrimSpace(scanner.Text()))
+	if err != nil || choice < 1 || choice > min(10, len(files)) {
+		fmt.Println("Invalid choice.")
+		return
+	}
+	r, err := loadReplay(files[choice-1])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Print("1. Verify  2. Watch: ")
+	scanner.Scan()
+	if strings.TrimSpace(scanner.Text()) == "2" {
+		if err := viewReplay(scanner, r); err != nil {
+			fmt.Printf("Replay failed: %v\n", err)
+		}
+		return
+	}
+	printVerification(r)
+}
+
+// storeReplay saves a finished match, tells the player where it went and returns the
+// path. Matches the server didn't record in full can't be played again and are not saved.
+func storeReplay(r *ReplayData, logger *Logger) string {
+	if r.Version != replayFormatVersion {
+		return ""
+	}
+	path, err := saveReplay(r)
+	if err != nil {
+		logger.Error("Error saving replay: %v", err)
+		return ""
+	}
+	fmt.Printf("Replay saved to %s\n", path)
+	return path
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordMatch plays a match between two strategies and records it the way playGame does
+func recordMatch(rules Ruleset, seed int64, player, enemy Opponent) ReplayData {
+	deck := func(names ...string) []clash.Card {
+		var cards []clash.Card
+		for _, name := range names {
+			cards = append(cards, clash.Card{Name: name, Level: 9})
+		}
+		return cards
+	}
+	playerDeck := deck("Giant", "Knight", "Musketeer", "Archers", "Fireball", "Zap", "Cannon", "Skeletons")
+	enemyDeck := deck("Hog Rider", "Mini P.E.K.K.A", "Minions", "Arrows", "Tombstone", "Fire Spirit", "Poison", "Goblin Gang")
+
+	state := newGameState(rules, seed, "A", "B", playerDeck, enemyDeck)
+	replay := ReplayData{
+		Version:  replayFormatVersion,
+		Seed:     seed,
+		Rules:    rules.ref(),
+		Player:   ReplayPlayer{Name: "A", Deck: playerDeck, Opening: state.PlayerCycle.Order()},
+		Opponent: ReplayPlayer{Name: "B", Deck: enemyDeck, Opening: state.EnemyCycle.Order()},
+	}
+	for {
+		for side, ai := range []Opponent{player, enemy} {
+			if move, ok := ai.Decide(&state, Side(side), state.strategyRandom(Side(side))); ok {
+				playCard(&state, Side(side), move)
+			}
+		}
+		advanceMatch(&state, tickInterval)
+		if end, _ := checkMatchEnd(&state); end.Over {
+			replay.Plays = state.Plays
+			replay.Final = replayFinal(&state, end)
+			return replay
+		}
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	for _, tt := range []struct {
+		ruleset  string
+		strategy string
+		seed     int64
+	}{
+		{"ladder", "elixir", 1},
+		{"ladder", "random", 2},
+		{"double-elixir", "counter", 3},
+		{"triple-elixir", "elixir", 4},
+	} {
+		t.Run(tt.ruleset+"/"+tt.strategy, func(t *testing.T) {
+			ai, err := newOpponent(tt.strategy, nil)
+			assert.Nil(t, err)
+			replay := recordMatch(rulesets[tt.ruleset], tt.seed, &ElixirOpponent{}, ai)
+			assert.NotEmpty(t, replay.Plays)
+
+			final, diffs, err := verifyReplay(&replay)
+			assert.Nil(t, err)
+			assert.Empty(t, diffs)
+			assert.Equal(t, replay.Final, final)
+
+			// A tampered result no longer matches the simulation
+			replay.Final.PlayerCrowns++
+			_, diffs, err = verifyReplay(&replay)
+			assert.Nil(t, err)
+			assert.Len(t, diffs, 1)
+		})
+	}
+
+	replay := recordMatch(rulesets["ladder"], 1, &ElixirOpponent{}, &ElixirOpponent{})
+	replay.Version++
+	_, _, err := verifyReplay(&replay)
+	assert.Error(t, err)
+}
+
+func TestSimulateReplay_Surrender(t *testing.T) {
+	replay := recordMatch(rulesets["ladder"], 5, &ElixirOpponent{}, &ElixirOpponent{})
+	replay.Surrender = &Surrender{Side: SideEnemy, At: replay.Plays[0].At}
+	state, end, err := simulateReplay(&replay, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, surrenderEnd(SideEnemy, false), end)
+	assert.Equal(t, replay.Plays[0].At, state.Elapsed)
+	assert.Equal(t, 3, state.Crowns.Player)
+}
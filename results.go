@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	// Connects to players.go: Match results are reported as a clash.Battle
+	"github.com/fiskie/go-clash/clash"
+)
+
+// CrownEvent records a tower taken during a match
+type CrownEvent struct {
+	Side  Side          // Side that took the crown
+	Tower string        // Tower that fell
+	At    time.Duration // Match time the tower fell at
+}
+
+// CrownTracker keeps the crowns each side took: 1 per guard tower and 3 for the King Tower
+type CrownTracker struct {
+	Player int
+	Enemy  int
+	Taken  []CrownEvent
+}
+
+// crowns returns the crowns a side has taken
+func (c *CrownTracker) crowns(side Side) int {
+	if side == SidePlayer {
+		return c.Player
+	}
+	return c.Enemy
+}
+
+// update records towers that fell since the last update and returns an event for each
+func (c *CrownTracker) update(state *GameState) []string {
+	var events []string
+	for _, side := range []Side{SidePlayer, SideEnemy} {
+		for _, tower := range state.towers(side.opposite()) {
+			if tower.HP > 0 || c.recorded(side, tower.Type) {
+				continue
+			}
+			c.Taken = append(c.Taken, CrownEvent{Side: side, Tower: tower.Type, At: state.Elapsed})
+			events = append(events, fmt.Sprintf("%s took the %s (%s)", state.sideName(side), tower.Type, matchClock(state.Elapsed)))
+		}
+	}
+	c.Player = crowns(state.EnemyTowers)
+	c.Enemy = crowns(state.PlayerTowers)
+	return events
+}
+
+// recorded reports whether a side already got the crown for a tower
+func (c *CrownTracker) recorded(side Side, tower string) bool {
+	for _, taken := range c.Taken {
+		if taken.Side == side && taken.Tower == tower {
+			return true
+		}
+	}
+	return false
+}
+
+// surrender gives the other side all three crowns
+func (c *CrownTracker) surrender(side Side) {
+	if side == SidePlayer {
+		c.Enemy = 3
+	} else {
+		c.Player = 3
+	}
+}
+
+// matchClock formats match time as m:ss
+func matchClock(elapsed time.Duration) string {
+	seconds := int(elapsed.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// battlePlayer fills in the tower and crown fields of a clash.BattlePlayer for a side.
+// Like the API, the King Tower hit points are left out once destroyed and only standing
+// princess towers are listed.
+func battlePlayer(state *GameState, side Side, base clash.BattlePlayer) clash.BattlePlayer {
+	base.Crowns = state.Crowns.crowns(side)
+	base.KingTowerHitPoints = 0
+	base.PrincessTowersHitPoints = nil
+	for _, tower := range state.towers(side) {
+		if tower.HP <= 0 {
+			continue
+		}
+		if tower.isKing() {
+			base.KingTowerHitPoints = tower.HP
+		} else {
+			base.PrincessTowersHitPoints = append(base.PrincessTowersHitPoints, tower.HP)
+		}
+	}
+	return base
+}
+
+// battleResult turns a finished match into a clash.Battle so Battle.Outcome() and other
+// battle log tooling work on simulated matches
+func battleResult(state *GameState, arena clash.Arena, team, opponent clash.BattlePlayer) clash.Battle {
+	return clash.Battle{
+		Type:          "simulation",
+		RawBattleTime: time.Now().UTC().Format(clash.TimeLayout),
+		Arena:         arena,
+		GameMode:      clash.GameMode{Name: state.Rules.Name},
+		DeckSelection: "collection",
+		Team:          []clash.BattlePlayer{battlePlayer(state, SidePlayer, team)},
+		Opponent:      []clash.BattlePlayer{battlePlayer(state, SideEnemy, opponent)},
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrownTracker_Update(t *testing.T) {
+	state := GameState{
+		PlayerTowers: newTowers(SidePlayer),
+		EnemyTowers:  newTowers(SideEnemy),
+		PlayerName:   "Player",
+		EnemyName:    "Enemy",
+		Elapsed:      75 * time.Second,
+	}
+	assert.Empty(t, state.Crowns.update(&state))
+
+	state.EnemyTowers[1].HP = 0
+	assert.Equal(t, []string{"Player took the Guard Tower 2 (1:15)"}, state.Crowns.update(&state))
+	// A tower is only counted once
+	assert.Empty(t, state.Crowns.update(&state))
+	assert.Equal(t, 1, state.Crowns.crowns(SidePlayer))
+
+	state.Elapsed = 2 * time.Minute
+	state.PlayerTowers[2].HP = 0
+	assert.Equal(t, []string{"Enemy took the King Tower (2:00)"}, state.Crowns.update(&state))
+	assert.Equal(t, 3, state.Crowns.crowns(SideEnemy))
+	assert.Equal(t, []CrownEvent{
+		{Side: SidePlayer, Tower: "Guard Tower 2", At: 75 * time.Second},
+		{Side: SideEnemy, Tower: "King Tower", At: 2 * time.Minute},
+	}, state.Crowns.Taken)
+}
+
+func TestCrownTracker_Surrender(t *testing.T) {
+	for _, tt := range []struct {
+		side   Side
+		crowns [2]int
+	}{
+		{SidePlayer, [2]int{1, 3}},
+		{SideEnemy, [2]int{3, 0}},
+	} {
+		c := CrownTracker{Player: 1}
+		c.surrender(tt.side)
+		assert.Equal(t, tt.crowns, [2]int{c.Player, c.Enemy})
+	}
+}
+
+func TestMatchClock(t *testing.T) {
+	for _, tt := range []struct {
+		elapsed time.Duration
+		clock   string
+	}{
+		{0, "0:00"},
+		{59600 * time.Millisecond, "1:00"},
+		{3*time.Minute + 5*time.Second, "3:05"},
+	} {
+		assert.Equal(t, tt.clock, matchClock(tt.elapsed))
+	}
+}
+
+func TestBattlePlayer(t *testing.T) {
+	state := GameState{PlayerTowers: newTowers(SidePlayer), EnemyTowers: newTowers(SideEnemy)}
+	state.PlayerTowers[0].HP = 0
+	state.PlayerTowers[1].HP = 640
+	state.EnemyTowers[2].HP = 0
+	state.Crowns.update(&state)
+
+	base := clash.BattlePlayer{Tag: "#2PP", Name: "A", Crowns: 9, PrincessTowersHitPoints: []int{1}}
+	player := battlePlayer(&state, SidePlayer, base)
+	assert.Equal(t, "#2PP", player.Tag)
+	assert.Equal(t, 3, player.Crowns)
+	assert.Equal(t, 2000, player.KingTowerHitPoints)
+	assert.Equal(t, []int{640}, player.PrincessTowersHitPoints)
+
+	// A destroyed King Tower is left out, like the API does
+	enemy := battlePlayer(&state, SideEnemy, clash.BattlePlayer{})
+	assert.Equal(t, 1, enemy.Crowns)
+	assert.Zero(t, enemy.KingTowerHitPoints)
+	assert.Equal(t, []int{1000, 1000}, enemy.PrincessTowersHitPoints)
+}
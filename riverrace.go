@@ -0,0 +1,588 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	// Connects to clans.go: The race is built from and reported as a clash.CurrentWar
+	"github.com/fiskie/go-clash/clash"
+)
+
+// A river race week: training days earn repair points, battle days earn fame. Every
+// member has four war decks a day and each deck can be used once a day.
+const (
+	trainingDays = 3
+	battleDays   = 4
+	decksPerDay  = 4
+	finishFame   = 10000 // Fame a clan needs to cross the finish line
+
+	battleWinFame    = 200
+	battleLossFame   = 100
+	boatWinFame      = 125
+	boatLossFame     = 75
+	repairWinPoints  = 100
+	repairLossPoints = 50
+	boatAttackChance = 0.2 // How often an AI member attacks a boat instead of battling
+)
+
+// raceTrophies is the clan war trophy change by finishing place
+var raceTrophies = []int{20, 10, 0, -10, -20}
+
+// What the human does with a war deck
+const (
+	raceBattle = "battle"
+	raceBoat   = "boat"
+	raceAuto   = "auto" // Let the AI use the rest of the human's decks
+)
+
+// RaceMember is a clan member taking part in a river race
+type RaceMember struct {
+	clash.WarParticipant
+	Decks [decksPerDay][]clash.Card
+	Human bool
+	clan  *RaceClan
+	used  int // War decks used today
+}
+
+// RaceClan is a clan taking part in a river race
+type RaceClan struct {
+	clash.WarClanDetails
+	Members  []*RaceMember
+	finished int // Place the clan crossed the finish line in, 0 until it does
+}
+
+// RiverRace simulates a river race week between clans. The human's battles are handed
+// to play; every other battle is simulated headlessly between two AIs.
+type RiverRace struct {
+	Clans []*RaceClan
+	Rules Ruleset
+	Start time.Time
+	Day   int
+
+	// ask asks the human what to do with their next war deck
+	ask func(human *RaceMember, deck int, training bool) string
+	// play plays one of the human's battles and returns its result
+	play func(human, opponent *RaceMember, deck, opponentDeck []clash.Card, boat bool) clash.Battle
+	rng  *rand.Rand
+	done int // Clans that crossed the finish line
+}
+
+// newRiverRace sets up a fresh race week between the clans of a river race. The fame,
+// repair points and decks used the API reports are cleared; war decks are dealt by the caller.
+func newRiverRace(war clash.CurrentWar, rules Ruleset, seed int64) (*RiverRace, error) {
+	race := &RiverRace{Rules: rules, Start: time.Now(), rng: rand.New(rand.NewSource(seed))}
+	clans := war.Clans
+	if len(clans) == 0 {
+		clans = []clash.WarClanDetails{war.Clan}
+	}
+	for _, details := range clans {
+		clan := &RaceClan{WarClanDetails: details}
+		clan.Fame, clan.RepairPoints, clan.RawFinishTime, clan.Participants = 0, 0, "", nil
+		participants := details.Participants
+		if clash.NormaliseTag(details.Tag) == clash.NormaliseTag(war.Clan.Tag) && len(participants) == 0 {
+			participants = war.Participants
+		}
+		for _, p := range participants {
+			clan.Members = append(clan.Members, &RaceMember{
+				WarParticipant: clash.WarParticipant{Tag: p.Tag, Name: p.Name},
+				clan:           clan,
+			})
+		}
+		if len(clan.Members) > 0 {
+			race.Clans = append(race.Clans, clan)
+		}
+	}
+	if len(race.Clans) < 2 {
+		return nil, fmt.Errorf("a river race needs at least two clans with members, found %d", len(race.Clans))
+	}
+	return race, nil
+}
+
+// member finds a clan member by tag
+func (r *RiverRace) member(tag string) *RaceMember {
+	for _, clan := range r.Clans {
+		for _, m := range clan.Members {
+			if clash.NormaliseTag(m.Tag) == clash.NormaliseTag(tag) {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// clan finds a clan by tag
+func (r *RiverRace) clan(tag string) *RaceClan {
+	for _, clan := range r.Clans {
+		if clash.NormaliseTag(clan.Tag) == clash.NormaliseTag(tag) {
+			return clan
+		}
+	}
+	return nil
+}
+
+// buildWarDecks deals four decks without repeating a card. The first deck is the
+// member's own deck when they have one; the others come from their collection first,
+// highest level first, and then from the card pool.
+func buildWarDecks(current, collection, pool []clash.Card, rng *rand.Rand) ([decksPerDay][]clash.Card, error) {
+	const deckSize = handSize * 2
+	var decks [decksPerDay][]clash.Card
+	used := map[string]bool{}
+	var cards []clash.Card
+	take := func(candidates []clash.Card) {
+		for _, card := range candidates {
+			if !used[card.Name] {
+				used[card.Name] = true
+				cards = append(cards, card)
+			}
+		}
+	}
+
+	if len(current) == deckSize {
+		take(current)
+	}
+	owned := append([]clash.Card(nil), collection...)
+	sort.SliceStable(owned, func(i, j int) bool { return owned[i].Level > owned[j].Level })
+	take(owned)
+	shuffled := append([]clash.Card(nil), pool...)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	take(shuffled)
+
+	if len(cards) < deckSize*decksPerDay {
+		return decks, fmt.Errorf("war decks need %d different cards, only %d are available", deckSize*decksPerDay, len(cards))
+	}
+	for i := range decks {
+		decks[i] = cards[i*deckSize : (i+1)*deckSize]
+	}
+	return decks, nil
+}
+
+// cardPool lists every card in the given decks and the card database once, at the
+// given level
+func cardPool(decks [][]clash.Card, level int) []clash.Card {
+	seen := map[string]bool{}
+	var pool []clash.Card
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			pool = append(pool, clash.Card{Name: name, Level: level})
+		}
+	}
+	for _, deck := range decks {
+		for _, card := range deck {
+			add(card.Name)
+		}
+	}
+	names := make([]string, 0, len(cardDatabase))
+	for name := range cardDatabase {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(name)
+	}
+	return pool
+}
+
+// averageLevel returns the average card level of a deck, rounded down
+func averageLevel(deck []clash.Card) int {
+	if len(deck) == 0 {
+		return 1
+	}
+	total := 0
+	for _, card := range deck {
+		total += card.Level
+	}
+	return max(1, total/len(deck))
+}
+
+// Run plays the whole week and returns the final standings in the shape the API
+// reports the current river race. ours is the tag of the human's clan.
+func (r *RiverRace) Run(ours string) clash.CurrentWar {
+	for r.Day = 1; r.Day <= trainingDays+battleDays; r.Day++ {
+		training := r.Day <= trainingDays
+		kind := "battle day"
+		if training {
+			kind = "training day"
+		}
+		fmt.Printf("\nDay %d of %d (%s)\n", r.Day, trainingDays+battleDays, kind)
+		r.playDay(training)
+		r.printDay(ours)
+	}
+	return r.Standings(ours)
+}
+
+// playDay uses every member's war decks once, in a random order through the day. A
+// member battles the next member of another clan waiting to play, and both use a deck.
+func (r *RiverRace) playDay(training bool) {
+	var queue []*RaceMember
+	for _, clan := range r.Clans {
+		for _, m := range clan.Members {
+			m.used = 0
+			for i := 0; i < decksPerDay; i++ {
+				queue = append(queue, m)
+			}
+		}
+	}
+	r.rng.Shuffle(len(queue), func(i, j int) { queue[i], queue[j] = queue[j], queue[i] })
+
+	total := len(queue)
+	for len(queue) > 0 {
+		at := r.Start.Add(time.Duration(r.Day-1)*24*time.Hour + time.Duration(total-len(queue))*24*time.Hour/time.Duration(total))
+		x := queue[0]
+		queue = queue[1:]
+
+		boat := !training && r.rng.Float64() < boatAttackChance
+		if x.Human && r.ask != nil {
+			switch r.ask(x, x.used, training) {
+			case raceBoat:
+				boat = !training
+			case raceAuto:
+				x.Human = false
+			default:
+				boat = false
+			}
+		}
+		if boat {
+			r.boatAttack(x, at)
+			continue
+		}
+
+		// The next member of another clan in the queue is the opponent. When nobody is
+		// left, a member of another clan who already played today takes the battle.
+		var y *RaceMember
+		for i, candidate := range queue {
+			if candidate.clan != x.clan {
+				y = candidate
+				queue = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+		r.battle(x, y, training, at)
+	}
+}
+
+// battle plays a war battle between two members. Without an opponent waiting, a random
+// member of another clan defends with one of their decks and earns nothing.
+func (r *RiverRace) battle(x, y *RaceMember, training bool, at time.Time) {
+	deckX := x.Decks[x.used]
+	x.used++
+	var deckY []clash.Card
+	if y != nil {
+		deckY = y.Decks[y.used]
+		y.used++
+	} else {
+		target := r.otherClan(x.clan)
+		y = target.Members[r.rng.Intn(len(target.Members))]
+		deckY = y.Decks[r.rng.Intn(decksPerDay)]
+		crownsX, crownsY := r.result(x, y, deckX, deckY, false)
+		r.award(x, crownsX > crownsY, training, false, at)
+		return
+	}
+
+	crownsX, crownsY := r.result(x, y, deckX, deckY, false)
+	r.award(x, crownsX > crownsY, training, false, at)
+	r.award(y, crownsY > crownsX, training, false, at)
+}
+
+// boatAttack attacks another clan's boat, defended by one of its members' war decks
+func (r *RiverRace) boatAttack(x *RaceMember, at time.Time) {
+	deck := x.Decks[x.used]
+	x.used++
+	target := r.otherClan(x.clan)
+	defender := target.Members[r.rng.Intn(len(target.Members))]
+	crowns, defended := r.result(x, defender, deck, defender.Decks[r.rng.Intn(decksPerDay)], true)
+	x.BoatAttacks++
+	r.award(x, crowns > defended, false, true, at)
+	if x.Human {
+		fmt.Printf("Boat attack on %s: %d-%d\n", target.Name, crowns, defended)
+	}
+}
+
+// otherClan picks a random clan other than the given one
+func (r *RiverRace) otherClan(clan *RaceClan) *RaceClan {
+	var others []*RaceClan
+	for _, c := range r.Clans {
+		if c != clan {
+			others = append(others, c)
+		}
+	}
+	return others[r.rng.Intn(len(others))]
+}
+
+// result plays a battle, handing it to the human when they are in it, and returns the
+// crowns each side took
+func (r *RiverRace) result(x, y *RaceMember, deckX, deckY []clash.Card, boat bool) (int, int) {
+	switch {
+	case x.Human && r.play != nil:
+		battle := r.play(x, y, deckX, deckY, boat)
+		return battle.Team[0].Crowns, battle.Opponent[0].Crowns
+	case y.Human && r.play != nil && !boat:
+		battle := r.play(y, x, deckY, deckX, boat)
+		return battle.Opponent[0].Crowns, battle.Team[0].Crowns
+	}
+	state, _ := headlessMatch(r.Rules, r.rng.Int63(), x.Name, y.Name, deckX, deckY)
+	return state.Crowns.Player, state.Crowns.Enemy
+}
+
+// riverRaceMode is what river race battles are recorded as. It isn't one of the
+// trophyModes: fame and repair points are the only reward.
+const riverRaceMode = "river race"
+
+// award gives a member and their clan the fame or repair points for a battle. A draw
+// earns what a loss does.
+func (r *RiverRace) award(m *RaceMember, won, training, boat bool, at time.Time) {
+	m.DecksUsed++
+	switch {
+	case training:
+		points := repairLossPoints
+		if won {
+			points = repairWinPoints
+		}
+		m.RepairPoints += points
+		m.clan.RepairPoints += points
+		return
+	case boat:
+		fame := boatLossFame
+		if won {
+			fame = boatWinFame
+		}
+		m.Fame += fame
+		m.clan.Fame += fame
+	default:
+		fame := battleLossFame
+		if won {
+			fame = battleWinFame
+		}
+		m.Fame += fame
+		m.clan.Fame += fame
+	}
+
+	if m.clan.finished == 0 && m.clan.Fame >= finishFame {
+		r.done++
+		m.clan.finished = r.done
+		m.clan.RawFinishTime = at.UTC().Format(clash.TimeLayout)
+		fmt.Printf("%s crossed the finish line in place %d!\n", m.clan.Name, r.done)
+	}
+}
+
+// ranked orders the clans like the race does: clans over the finish line by the order
+// they crossed it, then the rest by fame and repair points
+func (r *RiverRace) ranked() []*RaceClan {
+	order := append([]*RaceClan(nil), r.Clans...)
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		switch {
+		case a.finished > 0 && b.finished > 0:
+			return a.finished < b.finished
+		case a.finished > 0 || b.finished > 0:
+			return a.finished > 0
+		case a.Fame != b.Fame:
+			return a.Fame > b.Fame
+		}
+		return a.RepairPoints > b.RepairPoints
+	})
+	return order
+}
+
+// printDay shows where every clan stands at the end of a day
+func (r *RiverRace) printDay(ours string) {
+	for i, clan := range r.ranked() {
+		marker := ""
+		if clash.NormaliseTag(clan.Tag) == clash.NormaliseTag(ours) {
+			marker = "  <- your clan"
+		}
+		fmt.Printf("%d. %-20s fame %6d  repair %6d%s\n", i+1, clan.Name, clan.Fame, clan.RepairPoints, marker)
+	}
+}
+
+// Standings returns the race as the API reports it, with every clan's participants and
+// its clan war trophies updated for the place it finished in
+func (r *RiverRace) Standings(ours string) clash.CurrentWar {
+	war := clash.CurrentWar{State: "ended"}
+	for i, clan := range r.ranked() {
+		details := clan.WarClanDetails
+		details.ClanScore = max(0, details.ClanScore+raceTrophies[min(i, len(raceTrophies)-1)])
+		details.Participants = nil
+		for _, m := range clan.Members {
+			details.Participants = append(details.Participants, m.WarParticipant)
+		}
+		sort.SliceStable(details.Participants, func(a, b int) bool {
+			return details.Participants[a].Fame+details.Participants[a].RepairPoints >
+				details.Participants[b].Fame+details.Participants[b].RepairPoints
+		})
+		war.Clans = append(war.Clans, details)
+		if clash.NormaliseTag(clan.Tag) == clash.NormaliseTag(ours) {
+			war.Clan = details
+			war.Participants = details.Participants
+		}
+	}
+	return war
+}
+
+// printRaceStandings prints the final standings of the clans and the top of the player's clan
+func printRaceStandings(war clash.CurrentWar, humanTag string) {
+	fmt.Println("\nRiver race standings:")
+	fmt.Printf("%4s  %-20s %6s %7s %7s  %s\n", "Rank", "Clan", "Fame", "Repair", "Trophy", "Finished")
+	for i, clan := range war.Clans {
+		finished := "-"
+		if clan.RawFinishTime != "" {
+			finished = clan.FinishTime().Local().Format("Mon 15:04")
+		}
+		change := raceTrophies[min(i, len(raceTrophies)-1)]
+		fmt.Printf("%4d  %-20s %6d %7d %+7d  %s\n", i+1, clan.Name, clan.Fame, clan.RepairPoints, change, finished)
+	}
+
+	fmt.Printf("\n%s members:\n", war.Clan.Name)
+	fmt.Printf("%-20s %6s %7s %5s %5s\n", "Name", "Fame", "Repair", "Boats", "Decks")
+	for _, p := range war.Participants {
+		marker := ""
+		if clash.NormaliseTag(p.Tag) == clash.NormaliseTag(humanTag) {
+			marker = "  <- you"
+		}
+		fmt.Printf("%-20s %6d %7d %5d %5d%s\n", p.Name, p.Fame, p.RepairPoints, p.BoatAttacks, p.DecksUsed, marker)
+	}
+}
+
+// localRiverRace builds a river race between the clans of the local players, the
+// player's clan first and at most four others. The player races alone for a clan no
+// local player is in.
+func localRiverRace(players []clash.Player, player clash.Player) clash.CurrentWar {
+	byClan := map[string]*clash.WarClanDetails{}
+	var tags []string
+	for _, p := range players {
+		if p.Clan.Tag == "" {
+			continue
+		}
+		clan, exists := byClan[p.Clan.Tag]
+		if !exists {
+			clan = &clash.WarClanDetails{Tag: p.Clan.Tag, Name: p.Clan.Name, BadgeId: p.Clan.BadgeID}
+			byClan[p.Clan.Tag] = clan
+			tags = append(tags, p.Clan.Tag)
+		}
+		clan.Participants = append(clan.Participants, clash.WarParticipant{Tag: p.Tag, Name: p.Name})
+	}
+	sort.Strings(tags)
+
+	ours, exists := byClan[player.Clan.Tag]
+	if !exists {
+		ours = &clash.WarClanDetails{Tag: player.Clan.Tag, Name: player.Clan.Name, BadgeId: player.Clan.BadgeID,
+			Participants: []clash.WarParticipant{{Tag: player.Tag, Name: player.Name}}}
+	}
+	war := clash.CurrentWar{Clan: *ours, Participants: ours.Participants, Clans: []clash.WarClanDetails{*ours}}
+	for _, tag := range tags {
+		if tag != player.Clan.Tag && len(war.Clans) < len(raceTrophies) {
+			war.Clans = append(war.Clans, *byClan[tag])
+		}
+	}
+	return war
+}
+
+// playRiverRace runs a river race week for the player's clan: the race the API reports,
+// or one against the local players' clans when the API has none. record keeps each of the player's battles in their history. War battles only earn
+// fame and repair points, so they are recorded in a mode that leaves trophies alone.
+func playRiverRace(scanner *LineReader, client clash.API, players *clash.Resolver, player clash.Player, locals []clash.Player,
+	rules Ruleset, logger *Logger, record func(replay *ReplayData, mode, replayPath string)) {
+	if player.Clan.Tag == "" {
+		fmt.Println("You are not in a clan. Returning to the menu.")
+		return
+	}
+	// Connects to clans.go: Fetches the river race via client.Clan(player.Clan.Tag).CurrentWar()
+	war, err := client.Clan(player.Clan.Tag).CurrentWar()
+	if err != nil {
+		logger.Error("Error fetching river race: %v", err)
+		if len(locals) == 0 {
+			fmt.Println("No river race found. Returning to the menu.")
+			return
+		}
+		fmt.Println("No river race found. Racing the local clans instead.")
+		war = localRiverRace(locals, player)
+	}
+
+	race, err := newRiverRace(war, rules, time.Now().UnixNano())
+	if err != nil {
+		fmt.Printf("%v. Returning to the menu.\n", err)
+		return
+	}
+	human := race.member(player.Tag)
+	if human == nil {
+		// The player joined too late to be listed, so they join their clan's boat now
+		clan := race.clan(player.Clan.Tag)
+		if clan == nil {
+			fmt.Println("Your clan is not in this river race. Returning to the menu.")
+			return
+		}
+		human = &RaceMember{WarParticipant: clash.WarParticipant{Tag: player.Tag, Name: player.Name}, clan: clan}
+		clan.Members = append(clan.Members, human)
+	}
+	human.Human = true
+
+	// Members whose collection we don't know get war decks from every card in the race
+	byTag := map[string]clash.Player{}
+	for _, p := range locals {
+		byTag[clash.NormaliseTag(p.Tag)] = p
+	}
+	decks := [][]clash.Card{player.CurrentDeck, player.Cards}
+	for _, clan := range race.Clans {
+		for _, m := range clan.Members {
+			if local, ok := byTag[clash.NormaliseTag(m.Tag)]; ok {
+				decks = append(decks, local.CurrentDeck)
+			}
+		}
+	}
+	pool := cardPool(decks, averageLevel(player.CurrentDeck))
+	for _, clan := range race.Clans {
+		for _, m := range clan.Members {
+			current, collection := byTag[clash.NormaliseTag(m.Tag)].CurrentDeck, []clash.Card(nil)
+			if m.Human {
+				current, collection = player.CurrentDeck, player.Cards
+			}
+			if m.Decks, err = buildWarDecks(current, collection, pool, race.rng); err != nil {
+				fmt.Printf("%v. Returning to the menu.\n", err)
+				return
+			}
+		}
+	}
+
+	fmt.Printf("\nRiver race: %d clans, %d training days and %d battle days, %d war decks a day\n",
+		len(race.Clans), trainingDays, battleDays, decksPerDay)
+	for i, deck := range human.Decks {
+		names := make([]string, len(deck))
+		for j, card := range deck {
+			names[j] = card.Name
+		}
+		fmt.Printf("War deck %d: %s\n", i+1, strings.Join(names, ", "))
+	}
+
+	race.ask = func(human *RaceMember, deck int, training bool) string {
+		prompt := "Press Enter to battle, b to attack a boat, or a to let the AI use the rest of your decks: "
+		if training {
+			prompt = "Press Enter to battle for repair points, or a to let the AI use the rest of your decks: "
+		}
+		fmt.Printf("\nDay %d, war deck %d. %s", race.Day, deck+1, prompt)
+		scanner.Scan()
+		switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+		case "a":
+			return raceAuto
+		case "b":
+			return raceBoat
+		}
+		return raceBattle
+	}
+	race.play = func(human, opponent *RaceMember, deck, opponentDeck []clash.Card, boat bool) clash.Battle {
+		// The opponent plays the war deck they were dealt
+		enemy := clash.PlayerRef{Tag: opponent.Tag, Name: opponent.Name, Deck: opponentDeck}
+		if boat {
+			enemy.Name = opponent.clan.Name + " boat (" + opponent.Name + ")"
+		}
+		warPlayer := player
+		warPlayer.CurrentDeck = deck
+		replay := playGame(scanner, players, warPlayer, enemy, rules, time.Now().UnixNano(), &ElixirOpponent{}, logger)
+		record(&replay, riverRaceMode, storeReplay(&replay, logger))
+		return replay.Result
+	}
+
+	printRaceStandings(race.Run(player.Clan.Tag), player.Tag)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildWarDecks(t *testing.T) {
+	cards := func(prefix string, n, level int) []clash.Card {
+		var cards []clash.Card
+		for i := 0; i < n; i++ {
+			cards = append(cards, clash.Card{Name: fmt.Sprintf("%s %d", prefix, i), Level: level})
+		}
+		return cards
+	}
+	own := cards("Own", 8, 11)
+
+	for _, tt := range []struct {
+		name                      string
+		current, collection, pool []clash.Card
+		first                     []clash.Card // The first deck, when it is known
+		err                       bool
+	}{
+		{"own deck first", own, append(cards("Owned", 20, 9), own...), cards("Pool", 10, 9), own, false},
+		{"collection by level", own[:5], append(cards("Low", 10, 7), cards("High", 8, 13)...), cards("Pool", 20, 9), cards("High", 8, 13), false},
+		{"pool only", nil, nil, cards("Pool", 40, 9), nil, false},
+		{"too few cards", own, cards("Own", 20, 11), cards("Pool", 10, 9), nil, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			decks, err := buildWarDecks(tt.current, tt.collection, tt.pool, rand.New(rand.NewSource(1)))
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			if tt.first != nil {
+				assert.Equal(t, tt.first, decks[0])
+			}
+			seen := map[string]bool{}
+			for _, deck := range decks {
+				assert.Len(t, deck, handSize*2)
+				for _, card := range deck {
+					assert.False(t, seen[card.Name], "%s is in two war decks", card.Name)
+					seen[card.Name] = true
+				}
+			}
+		})
+	}
+}
+
+func TestRiverRace_Award(t *testing.T) {
+	for _, tt := range []struct {
+		name                string
+		won, training, boat bool
+		fame, repair        int
+	}{
+		{"battle win", true, false, false, battleWinFame, 0},
+		{"battle loss", false, false, false, battleLossFame, 0},
+		{"boat win", true, false, true, boatWinFame, 0},
+		{"boat loss", false, false, true, boatLossFame, 0},
+		{"training win", true, true, false, 0, repairWinPoints},
+		{"training loss", false, true, false, 0, repairLossPoints},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clan := &RaceClan{}
+			member := &RaceMember{clan: clan}
+			(&RiverRace{}).award(member, tt.won, tt.training, tt.boat, time.Now())
+			assert.Equal(t, 1, member.DecksUsed)
+			assert.Equal(t, tt.fame, member.Fame)
+			assert.Equal(t, tt.fame, clan.Fame)
+			assert.Equal(t, tt.repair, member.RepairPoints)
+			assert.Equal(t, tt.repair, clan.RepairPoints)
+			assert.Equal(t, 0, clan.finished)
+		})
+	}
+
+	// Clans are placed in the order they cross the finish line
+	race := &RiverRace{}
+	first, second := &RaceClan{}, &RaceClan{}
+	first.Fame, second.Fame = finishFame-battleWinFame, finishFame-battleLossFame
+	race.award(&RaceMember{clan: first}, true, false, false, time.Now())
+	race.award(&RaceMember{clan: second}, false, false, false, time.Now())
+	race.award(&RaceMember{clan: first}, true, false, false, time.Now())
+	assert.Equal(t, 1, first.finished)
+	assert.Equal(t, 2, second.finished)
+	assert.NotEmpty(t, first.RawFinishTime)
+}
+
+func TestLocalRiverRace(t *testing.T) {
+	locals := []clash.Player{
+		{Tag: "#PLAYER1", Name: "One", Clan: clash.PlayerClan{Tag: "#CLAN2", Name: "Two"}},
+		{Tag: "#PLAYER2", Name: "Two", Clan: clash.PlayerClan{Tag: "#CLAN1", Name: "One"}},
+		{Tag: "#PLAYER3", Name: "Three", Clan: clash.PlayerClan{Tag: "#CLAN1", Name: "One"}},
+		{Tag: "#PLAYER4", Name: "Clanless"},
+	}
+	for _, tt := range []struct {
+		name   string
+		player clash.Player
+		clans  []string
+		ours   int // Participants of the player's clan
+	}{
+		{"local clan", clash.Player{Tag: "#2PP", Clan: clash.PlayerClan{Tag: "#CLAN2"}}, []string{"#CLAN2", "#CLAN1"}, 1},
+		{"own clan", clash.Player{Tag: "#2PP", Name: "Human", Clan: clash.PlayerClan{Tag: "#8QU"}}, []string{"#8QU", "#CLAN1", "#CLAN2"}, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			war := localRiverRace(locals, tt.player)
+			var clans []string
+			for _, clan := range war.Clans {
+				clans = append(clans, clan.Tag)
+			}
+			assert.Equal(t, tt.clans, clans)
+			assert.Len(t, war.Participants, tt.ours)
+
+			race, err := newRiverRace(war, rulesets["ladder"], 1)
+			assert.Nil(t, err)
+			assert.Equal(t, len(tt.clans), len(race.Clans))
+		})
+	}
+}
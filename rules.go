@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ElixirPhase multiplies elixir regeneration from a point in the match onwards
+type ElixirPhase struct {
+	At         time.Duration `json:"at"`
+	Multiplier float64       `json:"multiplier"`
+	Name       string        `json:"name"`
+}
+
+// Ruleset bundles the timing and elixir rules of a match
+type Ruleset struct {
+	Name           string        `json:"name"`
+	Version        int           `json:"version"`
+	StartingElixir float64       `json:"startingElixir"`
+	MaxElixir      float64       `json:"maxElixir"`
+	ElixirInterval time.Duration `json:"elixirInterval"` // Time to regenerate one elixir at normal speed
+	RegularTime    time.Duration `json:"regularTime"`
+	Overtime       time.Duration `json:"overtime"` // Sudden death: the first tower taken wins
+	Phases         []ElixirPhase `json:"phases"`   // Sorted by At, the first phase starts at zero
+}
+
+// rulesets are the rulesets a match can be played with
+var rulesets = map[string]Ruleset{
+	"ladder": {
+		Name:           "ladder",
+		Version:        1,
+		StartingElixir: 5,
+		MaxElixir:      10,
+		ElixirInterval: 2800 * time.Millisecond,
+		RegularTime:    3 * time.Minute,
+		Overtime:       2 * time.Minute,
+		Phases: []ElixirPhase{
+			{At: 0, Multiplier: 1, Name: "Single elixir"},
+			{At: 2 * time.Minute, Multiplier: 2, Name: "Double elixir"},
+			{At: 4 * time.Minute, Multiplier: 3, Name: "Triple elixir"},
+		},
+	},
+	"double-elixir": {
+		Name:           "double-elixir",
+		Version:        1,
+		StartingElixir: 7,
+		MaxElixir:      10,
+		ElixirInterval: 2800 * time.Millisecond,
+		RegularTime:    3 * time.Minute,
+		Overtime:       2 * time.Minute,
+		Phases: []ElixirPhase{
+			{At: 0, Multiplier: 2, Name: "Double elixir"},
+			{At: 2 * time.Minute, Multiplier: 3, Name: "Triple elixir"},
+		},
+	},
+	"triple-elixir": {
+		Name:           "triple-elixir",
+		Version:        1,
+		StartingElixir: 10,
+		MaxElixir:      10,
+		ElixirInterval: 2800 * time.Millisecond,
+		RegularTime:    3 * time.Minute,
+		Overtime:       2 * time.Minute,
+		Phases: []ElixirPhase{
+			{At: 0, Multiplier: 3, Name: "Triple elixir"},
+		},
+	},
+}
+
+// defaultRuleset is used when no ruleset is chosen
+const defaultRuleset = "ladder"
+
+// lookupRuleset returns a ruleset by name
+func lookupRuleset(name string) (Ruleset, error) {
+	if rules, exists := rulesets[name]; exists {
+		return rules, nil
+	}
+	names := make([]string, 0, len(rulesets))
+	for n := range rulesets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return Ruleset{}, fmt.Errorf("unknown ruleset %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// phase returns the elixir phase active at a point in the match
+func (r Ruleset) phase(elapsed time.Duration) ElixirPhase {
+	current := ElixirPhase{Multiplier: 1}
+	for _, p := range r.Phases {
+		if elapsed >= p.At {
+			current = p
+		}
+	}
+	return current
+}
+
+// matchLength returns the longest a match can last, overtime included
+func (r Ruleset) matchLength() time.Duration {
+	return r.RegularTime + r.Overtime
+}
+
+// MatchEnd describes whether and how a match finished
+type MatchEnd struct {
+	Over   bool
+	Draw   bool
+	Winner Side
+	Reason string
+}
+
+// crowns counts the crowns taken from a side's towers: 1 per guard tower and 3 for the King Tower
+func crowns(towers []Tower) int {
+	taken := 0
+	for _, tower := range towers {
+		if tower.HP > 0 {
+			continue
+		}
+		if tower.isKing() {
+			return 3
+		}
+		taken++
+	}
+	return taken
+}
+
+// lowestTowerHP returns the hit points of a side's weakest standing tower
+func lowestTowerHP(towers []Tower) int {
+	lowest := 0
+	for _, tower := range towers {
+		if tower.HP > 0 && (lowest == 0 || tower.HP < lowest) {
+			lowest = tower.HP
+		}
+	}
+	return lowest
+}
+
+// advanceMatch moves the match clock forward by dt: elixir regenerates at the current
+// phase's rate and the battle is stepped. Phase changes and overtime are announced.
+func advanceMatch(state *GameState, dt time.Duration) []string {
+	var events []string
+	before, after := state.Elapsed, state.Elapsed+dt
+	tied := state.Crowns.Player == state.Crowns.Enemy
+	if before < state.Rules.RegularTime && after >= state.Rules.RegularTime && state.Rules.Overtime > 0 && tied {
+		events = append(events, "Overtime! The first tower taken wins")
+	}
+	if phase := state.Rules.phase(after); phase.Name != state.Rules.phase(before).Name {
+		events = append(events, phase.Name+"!")
+	}
+	state.Elapsed = after
+
+	regen := dt.Seconds() / state.Rules.ElixirInterval.Seconds() * state.Rules.phase(after).Multiplier
+	state.addElixir(SidePlayer, regen)
+	state.addElixir(SideEnemy, regen)
+
+	events = append(events, stepBattle(state, dt.Seconds())...)
+	return append(events, state.Crowns.update(state)...)
+}
+
+// checkMatchEnd decides whether a match is over. Destroying the King Tower wins outright.
+// Once regular time is up the side with more crowns wins. At the end of overtime ties are
+// broken like in the game: the weakest tower on the field falls and gives its crown away,
+// and the crown it gives is returned as an event.
+func checkMatchEnd(state *GameState) (MatchEnd, []string) {
+	if isKingTowerDestroyed(state.EnemyTowers) {
+		return MatchEnd{Over: true, Winner: SidePlayer, Reason: "destroyed the King Tower"}, nil
+	}
+	if isKingTowerDestroyed(state.PlayerTowers) {
+		return MatchEnd{Over: true, Winner: SideEnemy, Reason: "destroyed the King Tower"}, nil
+	}
+	if state.Elapsed < state.Rules.RegularTime {
+		return MatchEnd{}, nil
+	}
+
+	playerCrowns, enemyCrowns := state.Crowns.Player, state.Crowns.Enemy
+	if playerCrowns != enemyCrowns {
+		reason := "took more crowns"
+		if state.Elapsed > state.Rules.RegularTime {
+			reason = "took a tower in overtime"
+		}
+		if playerCrowns > enemyCrowns {
+			return MatchEnd{Over: true, Winner: SidePlayer, Reason: reason}, nil
+		}
+		return MatchEnd{Over: true, Winner: SideEnemy, Reason: reason}, nil
+	}
+	if state.Elapsed < state.Rules.matchLength() {
+		return MatchEnd{}, nil
+	}
+
+	playerLowest, enemyLowest := lowestTowerHP(state.PlayerTowers), lowestTowerHP(state.EnemyTowers)
+	switch {
+	case playerLowest > enemyLowest:
+		destroyWeakestTower(state.EnemyTowers)
+		return MatchEnd{Over: true, Winner: SidePlayer, Reason: "won the tiebreak on tower HP"}, state.Crowns.update(state)
+	case enemyLowest > playerLowest:
+		destroyWeakestTower(state.PlayerTowers)
+		return MatchEnd{Over: true, Winner: SideEnemy, Reason: "won the tiebreak on tower HP"}, state.Crowns.update(state)
+	}
+	return MatchEnd{Over: true, Draw: true, Reason: "time ran out with equal crowns and tower HP"}, nil
+}
+
+// destroyWeakestTower knocks down a side's standing tower with the lowest HP
+func destroyWeakestTower(towers []Tower) {
+	lowest := lowestTowerHP(towers)
+	for i := range towers {
+		if towers[i].HP > 0 && towers[i].HP == lowest {
+			towers[i].HP = 0
+			return
+		}
+	}
+}
+
+// timeLeft formats the remaining regular time or overtime for display
+func timeLeft(state *GameState) string {
+	left := state.Rules.RegularTime - state.Elapsed
+	label := "Time left"
+	if left <= 0 {
+		left = state.Rules.matchLength() - state.Elapsed
+		label = "Overtime left"
+	}
+	if left < 0 {
+		left = 0
+	}
+	return fmt.Sprintf("%s %s (%s)", label, matchClock(left), state.Rules.phase(state.Elapsed).Name)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleset_Phase(t *testing.T) {
+	rules := rulesets["ladder"]
+	for _, tt := range []struct {
+		elapsed time.Duration
+		phase   string
+	}{
+		{0, "Single elixir"},
+		{2*time.Minute - time.Millisecond, "Single elixir"},
+		{2 * time.Minute, "Double elixir"},
+		{4 * time.Minute, "Triple elixir"},
+	} {
+		assert.Equal(t, tt.phase, rules.phase(tt.elapsed).Name, tt.elapsed.String())
+	}
+}
+
+func TestAdvanceMatch(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		elapsed time.Duration
+		dt      time.Duration
+		elixir  float64
+		events  []string
+	}{
+		{"regenerates elixir", 0, 2800 * time.Millisecond, 6, nil},
+		{"double elixir", 2*time.Minute - 2800*time.Millisecond, 2800 * time.Millisecond, 7, []string{"Double elixir!"}},
+		{"overtime", 3*time.Minute - 1400*time.Millisecond, 1400 * time.Millisecond, 6, []string{"Overtime! The first tower taken wins"}},
+		{"capped", 0, time.Minute, 10, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := rulesets["ladder"]
+			state := GameState{
+				PlayerTowers: newTowers(SidePlayer),
+				EnemyTowers:  newTowers(SideEnemy),
+				PlayerElixir: rules.StartingElixir,
+				EnemyElixir:  rules.StartingElixir,
+				Rules:        rules,
+				Elapsed:      tt.elapsed,
+			}
+			assert.Equal(t, tt.events, advanceMatch(&state, tt.dt))
+			assert.Equal(t, tt.elapsed+tt.dt, state.Elapsed)
+			assert.InDelta(t, tt.elixir, state.PlayerElixir, 1e-9)
+			assert.InDelta(t, tt.elixir, state.EnemyElixir, 1e-9)
+		})
+	}
+}
+
+func TestCheckMatchEnd(t *testing.T) {
+	// Guard Tower 1 and the King Tower are indexes 0 and 2 of newTowers
+	for _, tt := range []struct {
+		name    string
+		elapsed time.Duration
+		setup   func(state *GameState)
+		end     MatchEnd
+		crowns  [2]int // After the match end is checked
+		events  []string
+	}{
+		{"regular time", time.Minute, func(state *GameState) {}, MatchEnd{}, [2]int{}, nil},
+		{"king tower", time.Minute, func(state *GameState) {
+			state.EnemyTowers[2].HP = 0
+		}, MatchEnd{Over: true, Winner: SidePlayer, Reason: "destroyed the King Tower"}, [2]int{3, 0}, nil},
+		{"own king tower", time.Minute, func(state *GameState) {
+			state.PlayerTowers[2].HP = 0
+		}, MatchEnd{Over: true, Winner: SideEnemy, Reason: "destroyed the King Tower"}, [2]int{0, 3}, nil},
+		{"more crowns", 3 * time.Minute, func(state *GameState) {
+			state.EnemyTowers[0].HP = 0
+		}, MatchEnd{Over: true, Winner: SidePlayer, Reason: "took more crowns"}, [2]int{1, 0}, nil},
+		{"tied into overtime", 3 * time.Minute, func(state *GameState) {
+			state.EnemyTowers[0].HP, state.PlayerTowers[0].HP = 0, 0
+		}, MatchEnd{}, [2]int{1, 1}, nil},
+		{"overtime tower", 4 * time.Minute, func(state *GameState) {
+			state.PlayerTowers[0].HP = 0
+		}, MatchEnd{Over: true, Winner: SideEnemy, Reason: "took a tower in overtime"}, [2]int{0, 1}, nil},
+		{"tiebreak", 5 * time.Minute, func(state *GameState) {
+			state.EnemyTowers[0].HP = 400
+			state.PlayerTowers[0].HP = 600
+		}, MatchEnd{Over: true, Winner: SidePlayer, Reason: "won the tiebreak on tower HP"}, [2]int{1, 0}, []string{"Player took the Guard Tower 1 (5:00)"}},
+		{"draw", 5 * time.Minute, func(state *GameState) {
+			state.EnemyTowers[0].HP = 400
+			state.PlayerTowers[0].HP = 400
+		}, MatchEnd{Over: true, Draw: true, Reason: "time ran out with equal crowns and tower HP"}, [2]int{}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			state := GameState{
+				PlayerTowers: newTowers(SidePlayer),
+				EnemyTowers:  newTowers(SideEnemy),
+				PlayerName:   "Player",
+				EnemyName:    "Enemy",
+				Rules:        rulesets["ladder"],
+				Elapsed:      tt.elapsed,
+			}
+			tt.setup(&state)
+			state.Crowns.update(&state)
+			end, events := checkMatchEnd(&state)
+			assert.Equal(t, tt.end, end)
+			assert.Equal(t, tt.events, events)
+			assert.Equal(t, tt.crowns, [2]int{state.Crowns.Player, state.Crowns.Enemy})
+		})
+	}
+}
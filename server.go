@@ -0,0 +1,422 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	// Connects to players.go: Players are identified by their clash.Player tag
+	"github.com/fiskie/go-clash/clash"
+)
+
+// Networking limits of the match server
+const (
+	defaultMatchAddr = "localhost:7777"
+	helloTimeout     = 10 * time.Second // A client must say hello this soon after connecting
+	writeTimeout     = 5 * time.Second  // A client that can't take a message this fast is dropped
+	reconnectGrace   = 30 * time.Second // A dropped player forfeits if they aren't back in time
+	maxCommandLag    = 50               // Plays older than this many ticks are rejected
+	outboxSize       = 64               // Messages queued for a client before it counts as lagging
+)
+
+// seat is one of the two players in a hosted match
+type seat struct {
+	side      Side
+	tag       string
+	name      string
+	trophies  int
+	deck      []clash.Card
+	conn      net.Conn
+	out       chan netMessage // Nil while the player is disconnected
+	base      *NetSnapshot    // Last snapshot queued for the client, diffs are taken against it
+	events    []string        // Events the client has not been sent yet
+	droppedAt time.Time
+	surrender bool
+}
+
+// joinRequest is a client that said hello and wants a seat
+type joinRequest struct {
+	conn  net.Conn
+	hello netMessage
+	reply chan joinReply
+}
+
+type joinReply struct {
+	side Side
+	err  error
+}
+
+// seatMessage is a message received from a seated client
+type seatMessage struct {
+	side Side
+	conn net.Conn
+	msg  netMessage // Zero when the connection dropped
+	gone bool
+}
+
+// MatchServer hosts one match between two networked players. The server runs the
+// battle engine and is the only one that changes the match; clients send the cards they
+// want to play and receive what changed every tick.
+type MatchServer struct {
+	rules    Ruleset
+	logger   *Logger
+	listener net.Listener
+	joins    chan joinRequest
+	messages chan seatMessage
+	done     chan struct{}
+	seats    [2]*seat
+}
+
+// listenMatch starts accepting players for a match on a TCP address
+func listenMatch(addr string, rules Ruleset, logger *Logger) (*MatchServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &MatchServer{
+		rules:    rules,
+		logger:   logger,
+		listener: listener,
+		joins:    make(chan joinRequest),
+		messages: make(chan seatMessage),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Addr returns the address the server listens on
+func (s *MatchServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Run waits for two players, plays the match and returns its result as seen by the
+// first player to join
+func (s *MatchServer) Run() clash.Battle {
+	defer close(s.done)
+	defer s.listener.Close()
+	go s.accept()
+
+	// Fill both seats
+	for s.seats[0] == nil || s.seats[1] == nil {
+		select {
+		case req := <-s.joins:
+			s.join(req, false)
+		case m := <-s.messages:
+			if m.gone {
+				s.leaveLobby(m)
+			}
+		}
+	}
+
+	seed := time.Now().UnixNano()
+	match := newGameState(s.rules, seed, s.seats[SidePlayer].name, s.seats[SideEnemy].name,
+		s.seats[SidePlayer].deck, s.seats[SideEnemy].deck)
+	state := &match
+	replay := &ReplayData{Version: replayFormatVersion, Seed: seed, Rules: s.rules.ref(), Actions: []string{}}
+	for _, st := range s.seats {
+		recorded := ReplayPlayer{Tag: st.tag, Name: st.name, Trophies: st.trophies, Deck: st.deck, Opening: state.cycle(st.side).Order()}
+		if st.side == SidePlayer {
+			replay.Player = recorded
+		} else {
+			replay.Opponent = recorded
+		}
+	}
+	for _, st := range s.seats {
+		s.send(st, netMessage{Type: "start", Opponent: s.seats[st.side.opposite()].name})
+	}
+	s.logger.Info("Match started: %s vs %s", state.PlayerName, state.EnemyName)
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	tick := 0
+	var plays []seatMessage
+	for {
+		select {
+		case req := <-s.joins:
+			s.join(req, true)
+			continue
+		case m := <-s.messages:
+			st := s.seats[m.side]
+			switch {
+			case st.conn != m.conn:
+				// A connection that was replaced by a reconnect
+			case m.gone:
+				s.drop(state, st)
+			case m.msg.Type == "play":
+				// Plays are applied on the next tick in the order they arrived
+				plays = append(plays, m)
+			case m.msg.Type == "surrender":
+				st.surrender = true
+			}
+			continue
+		case <-ticker.C:
+		}
+
+		tick++
+		var events []string
+		for _, m := range plays {
+			if action, ok := s.play(state, tick, m); ok {
+				events = append(events, action)
+			}
+		}
+		plays = plays[:0]
+		events = append(events, advanceMatch(state, tickInterval)...)
+
+		end, ended := checkMatchEnd(state)
+		events = append(events, ended...)
+		for _, st := range s.seats {
+			if st.surrender || (st.out == nil && time.Since(st.droppedAt) > reconnectGrace) {
+				state.Crowns.surrender(st.side)
+				replay.Surrender = &Surrender{Side: st.side, At: state.Elapsed, Left: !st.surrender}
+				end = surrenderEnd(st.side, !st.surrender)
+			}
+		}
+		replay.Actions = append(replay.Actions, events...)
+
+		for _, st := range s.seats {
+			st.events = append(st.events, events...)
+			s.sendState(state, st, tick)
+		}
+		if end.Over {
+			return s.finish(state, end, replay)
+		}
+	}
+}
+
+// accept hands every new connection to its own goroutine until the match is over
+func (s *MatchServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve reads a client's hello, asks for a seat and then forwards its messages to the
+// match loop until the connection drops
+func (s *MatchServer) serve(conn net.Conn) {
+	decoder := json.NewDecoder(conn)
+	var hello netMessage
+	conn.SetReadDeadline(time.Now().Add(helloTimeout))
+	if err := decoder.Decode(&hello); err != nil || hello.Type != "hello" {
+		conn.Close()
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	reply := make(chan joinReply, 1)
+	select {
+	case s.joins <- joinRequest{conn: conn, hello: hello, reply: reply}:
+	case <-s.done:
+		conn.Close()
+		return
+	}
+	joined := <-reply
+	if joined.err != nil {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		json.NewEncoder(conn).Encode(netMessage{Type: "error", Error: joined.err.Error()})
+		conn.Close()
+		return
+	}
+
+	for {
+		var msg netMessage
+		err := decoder.Decode(&msg)
+		m := seatMessage{side: joined.side, conn: conn, msg: msg, gone: err != nil}
+		select {
+		case s.messages <- m:
+		case <-s.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// join seats a player, or puts a player who dropped back in their seat. A returning
+// player gets the whole match state again.
+func (s *MatchServer) join(req joinRequest, started bool) {
+	hello := req.hello
+	parsed, err := clash.ParseTag(hello.Tag)
+	if err != nil {
+		req.reply <- joinReply{err: err}
+		return
+	}
+	tag := parsed.String()
+
+	for _, st := range s.seats {
+		if st != nil && st.tag == tag {
+			s.attach(st, req.conn)
+			req.reply <- joinReply{side: st.side}
+			s.send(st, netMessage{Type: "welcome", Tag: tag, Rules: &s.rules})
+			if started {
+				other := s.seats[st.side.opposite()]
+				s.send(st, netMessage{Type: "start", Opponent: other.name})
+				other.events = append(other.events, st.name+" reconnected")
+			}
+			s.logger.Info("%s (%s) reconnected", st.name, tag)
+			return
+		}
+	}
+
+	if started {
+		req.reply <- joinReply{err: fmt.Errorf("the match is full")}
+		return
+	}
+	if len(hello.Deck) == 0 {
+		req.reply <- joinReply{err: fmt.Errorf("a deck is required")}
+		return
+	}
+	side := SidePlayer
+	if s.seats[SidePlayer] != nil {
+		side = SideEnemy
+	}
+	st := &seat{side: side, tag: tag, name: hello.Name, trophies: hello.Trophies, deck: hello.Deck}
+	if st.name == "" {
+		st.name = tag
+	}
+	s.seats[side] = st
+	s.attach(st, req.conn)
+	req.reply <- joinReply{side: side}
+	s.send(st, netMessage{Type: "welcome", Tag: tag, Rules: &s.rules})
+	if s.seats[side.opposite()] == nil {
+		s.send(st, netMessage{Type: "wait"})
+	}
+	s.logger.Info("%s (%s) joined", st.name, tag)
+}
+
+// leaveLobby frees the seat of a player who left before the match started
+func (s *MatchServer) leaveLobby(m seatMessage) {
+	if st := s.seats[m.side]; st != nil && st.conn == m.conn {
+		close(st.out)
+		s.seats[m.side] = nil
+		s.logger.Info("%s left before the match started", st.name)
+	}
+}
+
+// attach connects a seat to a client connection, replacing any previous one
+func (s *MatchServer) attach(st *seat, conn net.Conn) {
+	if st.out != nil {
+		close(st.out)
+	}
+	st.conn = conn
+	st.out = make(chan netMessage, outboxSize)
+	st.base = nil
+	go writeMessages(conn, st.out)
+}
+
+// drop marks a player as disconnected. The match goes on and they can reconnect with the
+// same tag until reconnectGrace runs out.
+func (s *MatchServer) drop(state *GameState, st *seat) {
+	close(st.out)
+	st.out = nil
+	st.conn = nil
+	st.base = nil
+	st.droppedAt = time.Now()
+	s.seats[st.side.opposite()].events = append(s.seats[st.side.opposite()].events,
+		fmt.Sprintf("%s disconnected (%s left to reconnect)", st.name, reconnectGrace))
+	s.logger.Info("%s disconnected at %s", st.name, matchClock(state.Elapsed))
+}
+
+// send queues a message for a client without ever blocking the match. It reports false
+// when the client is disconnected or too far behind to take it.
+func (s *MatchServer) send(st *seat, msg netMessage) bool {
+	if st.out == nil {
+		return false
+	}
+	select {
+	case st.out <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendState sends a client what changed since the last snapshot it was sent. A client
+// that is lagging skips ticks and catches up with a bigger diff once it drains.
+func (s *MatchServer) sendState(state *GameState, st *seat, tick int) {
+	snap := netSnapshot(state, st.side, tick)
+	diff := diffSnapshots(st.base, snap)
+	if s.send(st, netMessage{Type: "state", Diff: &diff, Events: st.events}) {
+		st.base = &snap
+		st.events = nil
+	}
+}
+
+// play applies a card play sent by a client. Plays are matched to the hand by card name,
+// so a play made from a slightly old view of the hand still plays the intended card.
+func (s *MatchServer) play(state *GameState, tick int, m seatMessage) (string, bool) {
+	st := s.seats[m.side]
+	reject := func(format string, v ...interface{}) (string, bool) {
+		s.send(st, netMessage{Type: "error", Error: fmt.Sprintf(format, v...)})
+		return "", false
+	}
+
+	if m.msg.Tick > tick {
+		return reject("play from tick %d is ahead of the server (tick %d)", m.msg.Tick, tick)
+	}
+	if tick-m.msg.Tick > maxCommandLag {
+		return reject("play of %s arrived %s late", m.msg.Card, time.Duration(tick-m.msg.Tick)*tickInterval)
+	}
+	slot := state.cycle(m.side).Slot(m.msg.Card)
+	if slot < 0 {
+		return reject("%s is not in your hand", m.msg.Card)
+	}
+	action, err := playCard(state, m.side, Move{Slot: slot, Lane: m.msg.Lane, Tile: m.msg.Tile})
+	if err != nil {
+		return reject("can't play %s: %v", m.msg.Card, err)
+	}
+	s.send(st, netMessage{Type: "played", Card: m.msg.Card, Lane: state.Plays[len(state.Plays)-1].Lane, Tile: m.msg.Tile, Tick: tick})
+	return action, true
+}
+
+// finish sends both players the result and closes their connections
+func (s *MatchServer) finish(state *GameState, end MatchEnd, replay *ReplayData) clash.Battle {
+	battlePlayers := [2]clash.BattlePlayer{}
+	for _, st := range s.seats {
+		battlePlayers[st.side] = clash.BattlePlayer{Tag: st.tag, Name: st.name, StartingTrophies: st.trophies, Cards: st.deck}
+	}
+	result := battleResult(state, clash.Arena{}, battlePlayers[SidePlayer], battlePlayers[SideEnemy])
+	replay.Plays, replay.Final, replay.Result = state.Plays, replayFinal(state, end), result
+
+	reason := "Match ended in a draw: " + end.Reason
+	if !end.Draw {
+		reason = fmt.Sprintf("%s %s", state.sideName(end.Winner), end.Reason)
+	}
+	for _, st := range s.seats {
+		// Each player sees themselves as the team
+		seen := result
+		if st.side == SideEnemy {
+			seen.Team, seen.Opponent = result.Opponent, result.Team
+		}
+		if st.out != nil {
+			// The result is worth waiting for even when the client is lagging
+			select {
+			case st.out <- netMessage{Type: "end", Result: &seen, Reason: reason, Replay: replay}:
+			case <-time.After(writeTimeout):
+			}
+			close(st.out)
+			st.out = nil
+		}
+	}
+	s.logger.Info("Match over: %s", reason)
+	return result
+}
+
+// writeMessages sends queued messages to a client until the queue is closed
+func writeMessages(conn net.Conn, out <-chan netMessage) {
+	defer conn.Close()
+	encoder := json.NewEncoder(conn)
+	for msg := range out {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := encoder.Encode(msg); err != nil {
+			// Drain the queue so the match never waits on a dead client
+			for range out {
+			}
+			return
+		}
+	}
+}
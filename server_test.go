@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+// pipeClient connects a fake client to the server and decodes what the server sends it
+func pipeClient() (net.Conn, <-chan netMessage) {
+	server, client := net.Pipe()
+	received := make(chan netMessage, outboxSize)
+	go func() {
+		defer close(received)
+		decoder := json.NewDecoder(client)
+		for {
+			var msg netMessage
+			if decoder.Decode(&msg) != nil {
+				return
+			}
+			received <- msg
+		}
+	}()
+	return server, received
+}
+
+// receive returns the types of the next n messages a client was sent
+func receive(t *testing.T, received <-chan netMessage, n int) []string {
+	var types []string
+	for len(types) < n {
+		select {
+		case msg := <-received:
+			types = append(types, msg.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("got %v, expected %d messages", types, n)
+		}
+	}
+	return types
+}
+
+func TestMatchServer_Join(t *testing.T) {
+	s := &MatchServer{rules: rulesets["ladder"], logger: &Logger{}}
+	deck := testDeck()
+	join := func(tag string, deck []clash.Card, started bool) (joinReply, <-chan netMessage) {
+		conn, received := pipeClient()
+		reply := make(chan joinReply, 1)
+		s.join(joinRequest{conn: conn, hello: netMessage{Type: "hello", Tag: tag, Deck: deck}, reply: reply}, started)
+		return <-reply, received
+	}
+
+	reply, _ := join("", deck, false)
+	assert.EqualError(t, reply.err, `invalid tag "": must be 3 to 10 characters long`)
+	reply, _ = join("#ABC", deck, false)
+	assert.True(t, clash.IsInvalidTagErr(reply.err))
+	reply, _ = join("2PP", nil, false)
+	assert.EqualError(t, reply.err, "a deck is required")
+
+	// The first player waits for the second
+	reply, first := join("2PP", deck, false)
+	assert.Nil(t, reply.err)
+	assert.Equal(t, SidePlayer, reply.side)
+	assert.Equal(t, []string{"welcome", "wait"}, receive(t, first, 2))
+	assert.Equal(t, "#2PP", s.seats[SidePlayer].name)
+
+	reply, second := join("#9LL", deck, false)
+	assert.Nil(t, reply.err)
+	assert.Equal(t, SideEnemy, reply.side)
+	assert.Equal(t, []string{"welcome"}, receive(t, second, 1))
+
+	// Nobody else gets in once the match is on
+	reply, _ = join("#8QU", deck, true)
+	assert.EqualError(t, reply.err, "the match is full")
+
+	// A dropped player can take their seat back
+	state := GameState{}
+	s.drop(&state, s.seats[SidePlayer])
+	assert.Nil(t, s.seats[SidePlayer].out)
+	assert.Equal(t, []string{"#2PP disconnected (30s left to reconnect)"}, s.seats[SideEnemy].events)
+	_, ok := <-first
+	assert.False(t, ok, "the dropped connection is closed")
+
+	reply, again := join("2PP", nil, true)
+	assert.Nil(t, reply.err)
+	assert.Equal(t, SidePlayer, reply.side)
+	assert.Equal(t, []string{"welcome", "start"}, receive(t, again, 2))
+	assert.Nil(t, s.seats[SidePlayer].base, "a returning player gets the whole state")
+	assert.Equal(t, "#2PP reconnected", s.seats[SideEnemy].events[1])
+}
+
+func TestMatchServer_LeaveLobby(t *testing.T) {
+	s := &MatchServer{rules: rulesets["ladder"], logger: &Logger{}}
+	conn, _ := pipeClient()
+	reply := make(chan joinReply, 1)
+	s.join(joinRequest{conn: conn, hello: netMessage{Tag: "#2PP", Deck: testDeck()}, reply: reply}, false)
+	<-reply
+
+	// A connection the seat no longer uses doesn't free it
+	stale, _ := net.Pipe()
+	s.leaveLobby(seatMessage{side: SidePlayer, conn: stale, gone: true})
+	assert.NotNil(t, s.seats[SidePlayer])
+
+	s.leaveLobby(seatMessage{side: SidePlayer, conn: conn, gone: true})
+	assert.Nil(t, s.seats[SidePlayer])
+}
+
+func TestMatchServer_Run(t *testing.T) {
+	s, err := listenMatch("localhost:0", rulesets["ladder"], &Logger{})
+	assert.Nil(t, err)
+	results := make(chan clash.Battle)
+	go func() { results <- s.Run() }()
+
+	deck := testDeck()
+	connect := func(tag string) (*json.Encoder, *json.Decoder) {
+		conn, err := net.Dial("tcp", s.Addr())
+		assert.Nil(t, err)
+		t.Cleanup(func() { conn.Close() })
+		encoder := json.NewEncoder(conn)
+		assert.Nil(t, encoder.Encode(netMessage{Type: "hello", Tag: tag, Name: tag, Deck: deck}))
+		return encoder, json.NewDecoder(conn)
+	}
+	// next skips to the next message of a type
+	next := func(decoder *json.Decoder, kind string) netMessage {
+		for {
+			var msg netMessage
+			if !assert.Nil(t, decoder.Decode(&msg)) || msg.Type == kind {
+				return msg
+			}
+		}
+	}
+
+	_, first := connect("#2PP")
+	next(first, "wait")
+	encoder, second := connect("#9LL")
+	assert.Equal(t, "#2PP", next(second, "start").Opponent)
+	assert.Equal(t, "#9LL", next(first, "start").Opponent)
+	state := next(first, "state")
+	assert.True(t, state.Diff.Full)
+
+	// A play from a tick the server hasn't reached is rejected
+	assert.Nil(t, encoder.Encode(netMessage{Type: "play", Card: deck[0].Name, Tick: 1 << 20}))
+	assert.Contains(t, next(second, "error").Error, "is ahead of the server")
+
+	assert.Nil(t, encoder.Encode(netMessage{Type: "surrender"}))
+	end := next(first, "end")
+	assert.Equal(t, "#2PP won because the opponent surrendered", end.Reason)
+	assert.Equal(t, 3, end.Result.Team[0].Crowns)
+	assert.Equal(t, 3, next(second, "end").Result.Opponent[0].Crowns)
+	assert.Equal(t, "#2PP", (<-results).Team[0].Name)
+}
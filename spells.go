@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	// Connects to players.go: Spells are cast from a clash.Card
+	"github.com/fiskie/go-clash/clash"
+)
+
+// SpellEffect is a spell lingering in the arena, such as Poison
+type SpellEffect struct {
+	Card      clash.Card
+	Stats     CardStats
+	Side      Side
+	Lane      Lane
+	Pos       float64 // Centre of the area, along the lane
+	Remaining float64 // Seconds until the spell wears off
+	pulse     float64 // Seconds until the next pulse of damage
+}
+
+// spellTarget picks where a spell lands in a lane: on the enemy unit that advanced the
+// furthest towards the caster, or on the tower the lane is pushing when there is none
+func spellTarget(state *GameState, side Side, lane Lane) float64 {
+	var target *Unit
+	for _, u := range state.Units {
+		if u.HP <= 0 || u.Side == side || u.Lane != lane {
+			continue
+		}
+		if target == nil || math.Abs(u.Pos-sidePosition(side, 0)) < math.Abs(target.Pos-sidePosition(side, 0)) {
+			target = u
+		}
+	}
+	if target != nil {
+		return target.Pos
+	}
+	if tower := laneTower(state.towers(side.opposite()), lane); tower != nil {
+		return tower.Pos
+	}
+	return sidePosition(side.opposite(), 0)
+}
+
+// spellArea returns the stretch of the lane a spell covers. Rolling spells cover their
+// whole path away from the caster.
+func spellArea(side Side, stats CardStats, center float64) (float64, float64) {
+	from, to := center-stats.Radius, center+stats.Radius
+	if stats.Roll > 0 {
+		if side == SidePlayer {
+			to += stats.Roll
+		} else {
+			from -= stats.Roll
+		}
+	}
+	return from, to
+}
+
+// castSpell drops a spell on a tile, or on the best target in a lane, and returns a replay
+// line describing it. Instant spells hit immediately while spells with a Duration linger
+// and pulse once per second.
+func castSpell(state *GameState, side Side, card clash.Card, stats CardStats, lane Lane, tile *Tile) string {
+	center := spellTarget(state, side, lane)
+	if tile != nil {
+		center = sidePosition(side, tile.distance())
+	} else {
+		// Report the tile the spell picked in the caster's coordinates
+		target := Tile{X: laneColumn(lane), Y: min(int(arenaLength)-1, int(sidePosition(side, center)))}
+		tile = &target
+	}
+	cast := fmt.Sprintf("%s cast %s (Level %d) %s", state.sideName(side), card.Name, card.Level, placement(lane, tile))
+
+	if stats.Duration > 0 {
+		state.Effects = append(state.Effects, &SpellEffect{
+			Card:      card,
+			Stats:     stats,
+			Side:      side,
+			Lane:      lane,
+			Pos:       center,
+			Remaining: stats.Duration,
+		})
+		return fmt.Sprintf("%s for %.0f seconds", cast, stats.Duration)
+	}
+
+	var crown []Tower
+	if tower := laneTower(state.towers(side.opposite()), lane); tower != nil {
+		crown = []Tower{*tower}
+	}
+	damage, cardCrit, towerCrit := calculateDamage(state.random(), card, stats, crown)
+	summary, events := spellHit(state, side, card, stats, lane, center, damage)
+	// Defeated units are reported together with the cast
+	return strings.Join(append([]string{cast + critLabel(cardCrit, towerCrit) + summary}, events...), "; ")
+}
+
+// spellHit applies a single hit of a spell to every enemy unit and tower in its area.
+// It returns a summary of the hit and the events for units it defeated.
+func spellHit(state *GameState, side Side, card clash.Card, stats CardStats, lane Lane, center float64, damage int) (string, []string) {
+	from, to := spellArea(side, stats, center)
+	var events []string
+	hits := 0
+	for _, u := range state.Units {
+		if u.HP <= 0 || u.Side == side || u.Lane != lane || u.Pos < from || u.Pos > to {
+			continue
+		}
+		hits++
+		events = append(events, damageUnit(state, side, card.Name, u, damage)...)
+		if stats.Stun > 0 {
+			u.Stunned = math.Max(u.Stunned, stats.Stun)
+		}
+		if stats.Knockback > 0 && !u.isBuilding() {
+			knockBack(u, side, stats.Knockback)
+		}
+	}
+
+	var parts []string
+	if hits > 0 {
+		units := "units"
+		if hits == 1 {
+			units = "unit"
+		}
+		parts = append(parts, fmt.Sprintf("hitting %d %s", hits, units))
+		if stats.Stun > 0 {
+			parts[0] += fmt.Sprintf(" (stunned for %.1fs)", stats.Stun)
+		}
+	}
+
+	towers := state.towers(side.opposite())
+	for i := range towers {
+		tower := &towers[i]
+		if tower.HP <= 0 || !tower.coversLane(lane) || tower.Pos+towerRadius < from || tower.Pos-towerRadius > to {
+			continue
+		}
+		// Crown towers only take a fraction of spell damage
+		dealt, result := applyDamage(tower, max(1, int(float64(damage)*stats.CrownTower)))
+		parts = append(parts, fmt.Sprintf("dealing %d damage to %s", dealt, result))
+	}
+
+	if len(parts) == 0 {
+		return " hitting nothing", events
+	}
+	return " " + strings.Join(parts, " and "), events
+}
+
+// knockBack pushes a unit away from the caster, keeping it inside the arena
+func knockBack(u *Unit, caster Side, distance float64) {
+	if caster == SidePlayer {
+		u.Pos = math.Min(arenaLength, u.Pos+distance)
+	} else {
+		u.Pos = math.Max(0, u.Pos-distance)
+	}
+}
+
+// stepEffects pulses lingering spells once per second and removes those that wore off
+func stepEffects(state *GameState, dt float64) []string {
+	var events []string
+	remaining := state.Effects[:0]
+	for _, effect := range state.Effects {
+		effect.pulse -= dt
+		if effect.pulse <= 0 {
+			effect.pulse += 1
+			damage := levelDamage(effect.Card, effect.Stats)
+			_, defeated := spellHit(state, effect.Side, effect.Card, effect.Stats, effect.Lane, effect.Pos, damage)
+			events = append(events, defeated...)
+		}
+
+		effect.Remaining -= dt
+		if effect.Remaining > 0 {
+			remaining = append(remaining, effect)
+		} else {
+			events = append(events, fmt.Sprintf("%s's %s in the %s lane wore off",
+				state.sideName(effect.Side), effect.Card.Name, effect.Lane))
+		}
+	}
+	state.Effects = remaining
+	return events
+}
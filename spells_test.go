@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpellArea(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		side     Side
+		stats    CardStats
+		from, to float64
+	}{
+		{"radius", SidePlayer, CardStats{Radius: 2}, 18, 22},
+		{"rolls away from the player", SidePlayer, CardStats{Radius: 1, Roll: 5}, 19, 26},
+		{"rolls away from the enemy", SideEnemy, CardStats{Radius: 1, Roll: 5}, 14, 21},
+	} {
+		from, to := spellArea(tt.side, tt.stats, 20)
+		assert.Equal(t, [2]float64{tt.from, tt.to}, [2]float64{from, to}, tt.name)
+	}
+}
+
+func TestSpellHit(t *testing.T) {
+	stats := CardStats{Radius: 2, CrownTower: 0.3, Stun: 0.5}
+	for _, tt := range []struct {
+		name    string
+		center  float64
+		hp      []int // Of the units below, in order
+		guard   int   // HP of the enemy's left guard tower
+		summary string
+	}{
+		{"units in the area", 20, []int{200, 300, 300, 300, 300}, 1000, " hitting 1 unit (stunned for 0.5s)"},
+		{"crown tower", 24, []int{300, 200, 300, 300, 300}, 973, " hitting 1 unit (stunned for 0.5s) and dealing 27 damage to Guard Tower 1 (HP now 973)"},
+		{"nothing", 12, []int{300, 300, 300, 300, 300}, 1000, " hitting nothing"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			state := GameState{PlayerTowers: newTowers(SidePlayer), EnemyTowers: newTowers(SideEnemy)}
+			state.Units = []*Unit{
+				{Side: SideEnemy, Lane: LaneLeft, HP: 300, Pos: 19},
+				{Side: SideEnemy, Lane: LaneLeft, HP: 300, Pos: 23},
+				{Side: SideEnemy, Lane: LaneRight, HP: 300, Pos: 20}, // Other lane
+				{Side: SidePlayer, Lane: LaneLeft, HP: 300, Pos: 20}, // Caster's own
+				{Side: SideEnemy, Lane: LaneLeft, HP: 300, Pos: 8},
+			}
+			summary, events := spellHit(&state, SidePlayer, clash.Card{Name: "Zap"}, stats, LaneLeft, tt.center, 100)
+			assert.Equal(t, tt.summary, summary)
+			assert.Empty(t, events)
+			for i, u := range state.Units {
+				assert.Equal(t, tt.hp[i], u.HP, "unit %d", i)
+				if u.HP < 300 {
+					assert.Equal(t, 0.5, u.Stunned)
+				}
+			}
+			assert.Equal(t, tt.guard, state.EnemyTowers[0].HP)
+		})
+	}
+}
+
+func TestKnockBack(t *testing.T) {
+	for _, tt := range []struct {
+		caster Side
+		pos    float64
+		after  float64
+	}{
+		{SidePlayer, 20, 21.5},
+		{SidePlayer, 31, arenaLength},
+		{SideEnemy, 20, 18.5},
+		{SideEnemy, 1, 0},
+	} {
+		u := &Unit{Pos: tt.pos}
+		knockBack(u, tt.caster, 1.5)
+		assert.Equal(t, tt.after, u.Pos)
+	}
+}
+
+func TestStepEffects(t *testing.T) {
+	state := GameState{PlayerTowers: newTowers(SidePlayer), EnemyTowers: newTowers(SideEnemy)}
+	target := &Unit{Side: SideEnemy, Lane: LaneLeft, HP: 200, Card: clash.Card{Name: "Knight"}, Pos: 15}
+	state.Units = []*Unit{target}
+	state.PlayerName, state.EnemyName = "Player", "Enemy"
+	state.Effects = []*SpellEffect{{
+		Card:      clash.Card{Name: "Poison", Level: 1},
+		Stats:     CardStats{BaseDamage: 100, Radius: 3, Duration: 3},
+		Side:      SidePlayer,
+		Lane:      LaneLeft,
+		Pos:       15,
+		Remaining: 3,
+	}}
+
+	// Poison pulses as it lands and once a second after that
+	assert.Empty(t, stepEffects(&state, 1))
+	assert.Equal(t, 100, target.HP)
+	assert.Equal(t, []string{"Player's Poison defeated Enemy's Knight"}, stepEffects(&state, 1))
+	assert.Len(t, state.Effects, 1)
+	assert.Equal(t, []string{"Player's Poison in the left lane wore off"}, stepEffects(&state, 1))
+	assert.Empty(t, state.Effects)
+}
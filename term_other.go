@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package main
+
+import "errors"
+
+// makeRaw is not supported here, the keyboard falls back to reading whole lines
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+// isTerminal can't tell terminals apart here, so the match screen is drawn in place
+// without switching screens
+func isTerminal(fd int) bool {
+	return false
+}
@@ -0,0 +1,40 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// makeRaw switches a terminal to reading single keys without echo. Reads return after
+// a tenth of a second even without input so the reader can be stopped. It returns a
+// function that restores the previous settings, or an error when fd is not a terminal.
+func makeRaw(fd int) (func(), error) {
+	var old syscall.Termios
+	if err := termios(fd, ioctlGetTermios, &old); err != nil {
+		return nil, err
+	}
+	raw := old
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO | syscall.ISIG // Ctrl-C arrives as a key
+	raw.Cc[syscall.VMIN] = 0
+	raw.Cc[syscall.VTIME] = 1
+	if err := termios(fd, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return func() { termios(fd, ioctlSetTermios, &old) }, nil
+}
+
+// isTerminal reports whether fd is a terminal
+func isTerminal(fd int) bool {
+	var t syscall.Termios
+	return termios(fd, ioctlGetTermios, &t) == nil
+}
+
+// termios gets or sets terminal settings
+func termios(fd int, request uintptr, t *syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(unsafe.Pointer(t))); errno != 0 {
+		return errno
+	}
+	return nil
+}
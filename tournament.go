@@ -0,0 +1,476 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	// Connects to tournaments.go: Brackets are built from a clash.Tournament and report clash.TournamentMember standings
+	"github.com/fiskie/go-clash/clash"
+)
+
+// Tournament formats
+const (
+	formatElimination = "elimination" // Losers are out, the last one standing wins
+	formatSwiss       = "swiss"       // Everyone plays every round against someone on the same score
+	formatMostWins    = "most-wins"   // Like the in-game tournaments: most wins before the time runs out
+)
+
+var tournamentFormats = []string{formatElimination, formatSwiss, formatMostWins}
+
+const (
+	defaultTournamentDuration = time.Hour        // For local rosters, which have no duration
+	matchmakingTime           = 30 * time.Second // Time between two matches in a most-wins tournament
+)
+
+// Entrant is a player in a simulated tournament
+type Entrant struct {
+	Tag      string
+	Name     string
+	Trophies int
+	Deck     []clash.Card
+	Human    bool            // Plays their own matches
+	Ref      clash.PlayerRef // Who the entrant is in the API
+
+	Score  int // 1 per win; byes count as wins
+	Wins   int
+	Losses int
+	Draws  int
+	Crowns int
+	met    map[string]bool
+	bye    bool
+}
+
+// Bracket runs a tournament between a roster of entrants. The human's matches are
+// handed to play; every other match is simulated headlessly between two AIs.
+type Bracket struct {
+	Name     string
+	Format   string
+	Rules    Ruleset
+	Duration time.Duration // Most-wins tournaments only
+	Entrants []*Entrant
+
+	// play plays the human's match and returns its result, or false when the human
+	// would rather let the AI play for them
+	play func(human, opponent *Entrant, round int) (clash.Battle, bool)
+	// deck loads an entrant's deck the first time it is needed
+	deck func(e *Entrant) []clash.Card
+	rng  *rand.Rand
+}
+
+// newBracket builds a bracket for a tournament. The human joins the roster, taking the
+// place of the lowest member if the tournament is at its maximum capacity.
+func newBracket(tournament clash.Tournament, format string, rules Ruleset, human *Entrant, seed int64) (*Bracket, error) {
+	switch format {
+	case formatElimination, formatSwiss, formatMostWins:
+	default:
+		return nil, fmt.Errorf("unknown tournament format %q (available: %s)", format, strings.Join(tournamentFormats, ", "))
+	}
+
+	b := &Bracket{
+		Name:     tournament.Name,
+		Format:   format,
+		Rules:    rules,
+		Duration: time.Duration(tournament.Duration) * time.Second,
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+	if b.Duration == 0 {
+		b.Duration = defaultTournamentDuration
+	}
+
+	capacity := tournament.MaxCapacity
+	if capacity == 0 {
+		capacity = len(tournament.MembersList) + 1
+	}
+	members := append([]clash.TournamentMember(nil), tournament.MembersList...)
+	sort.SliceStable(members, func(i, j int) bool { return members[i].Rank < members[j].Rank })
+	b.Entrants = append(b.Entrants, human)
+	for _, member := range members {
+		if len(b.Entrants) == capacity {
+			break
+		}
+		if clash.NormaliseTag(member.Tag) == clash.NormaliseTag(human.Tag) {
+			continue
+		}
+		b.Entrants = append(b.Entrants, &Entrant{Tag: member.Tag, Name: member.Name, Trophies: member.Score, Ref: member.Ref()})
+	}
+	if len(b.Entrants) < 2 {
+		return nil, fmt.Errorf("tournament %s has nobody to play against", tournament.Name)
+	}
+	for _, e := range b.Entrants {
+		e.met = map[string]bool{}
+	}
+	return b, nil
+}
+
+// Run plays every round and returns the final standings
+func (b *Bracket) Run() []clash.TournamentMember {
+	fmt.Printf("\n%s: %d players, %s format\n", b.Name, len(b.Entrants), b.Format)
+	switch b.Format {
+	case formatElimination:
+		b.runElimination()
+	case formatSwiss:
+		b.runSwiss()
+	default:
+		b.runMostWins()
+	}
+	return b.Standings()
+}
+
+// runElimination plays knockout rounds, seeded by trophies. Top seeds get byes so the
+// second round has a power of two players; a drawn match goes to a coin flip.
+func (b *Bracket) runElimination() {
+	alive := append([]*Entrant(nil), b.Entrants...)
+	sort.SliceStable(alive, func(i, j int) bool { return alive[i].Trophies > alive[j].Trophies })
+	for round := 1; len(alive) > 1; round++ {
+		size := 1
+		for size < len(alive) {
+			size *= 2
+		}
+		byes := size - len(alive)
+		next := append([]*Entrant(nil), alive[:byes]...)
+		for _, e := range next {
+			e.Score++
+			e.bye = true
+		}
+
+		// Highest remaining seed plays the lowest
+		rest := alive[byes:]
+		for i := 0; i < len(rest)/2; i++ {
+			x, y := rest[i], rest[len(rest)-1-i]
+			winner := b.match(x, y, round)
+			if winner == nil {
+				winner = x
+				if b.rng.Intn(2) == 1 {
+					winner = y
+				}
+				winner.Score++
+				b.announce(x, y, "%s goes through on a coin flip", winner.Name)
+			}
+			next = append(next, winner)
+		}
+		b.roundDone(round, 0)
+		alive = next
+	}
+}
+
+// runSwiss plays enough rounds to separate the field, pairing players on the same score
+// who haven't met yet. With an odd field the lowest player without a bye sits out and
+// gets the win.
+func (b *Bracket) runSwiss() {
+	rounds := 1
+	for 1<<rounds < len(b.Entrants) {
+		rounds++
+	}
+	for round := 1; round <= rounds; round++ {
+		order := b.ranked()
+		if len(order)%2 == 1 {
+			for i := len(order) - 1; i >= 0; i-- {
+				if !order[i].bye {
+					order[i].bye = true
+					order[i].Score++
+					order = append(order[:i:i], order[i+1:]...)
+					break
+				}
+			}
+		}
+
+		paired := map[*Entrant]bool{}
+		for i, x := range order {
+			if paired[x] {
+				continue
+			}
+			var y *Entrant
+			for _, candidate := range order[i+1:] {
+				if paired[candidate] {
+					continue
+				}
+				if y == nil {
+					y = candidate // A rematch if nobody new is left
+				}
+				if !x.met[candidate.Tag] {
+					y = candidate
+					break
+				}
+			}
+			if y == nil {
+				break
+			}
+			paired[x], paired[y] = true, true
+			b.match(x, y, round)
+		}
+		b.roundDone(round, rounds)
+	}
+}
+
+// runMostWins pairs everyone at random for as many rounds as fit in the tournament's
+// duration. With an odd field someone sits the round out.
+func (b *Bracket) runMostWins() {
+	rounds := max(1, int(b.Duration/(b.Rules.matchLength()+matchmakingTime)))
+	for round := 1; round <= rounds; round++ {
+		order := append([]*Entrant(nil), b.Entrants...)
+		b.rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		for i := 0; i+1 < len(order); i += 2 {
+			b.match(order[i], order[i+1], round)
+		}
+		b.roundDone(round, rounds)
+	}
+}
+
+// match plays two entrants against each other and updates their records. It returns
+// the winner, or nil for a draw.
+func (b *Bracket) match(x, y *Entrant, round int) *Entrant {
+	var crownsX, crownsY int
+	played := false
+	if y.Human {
+		x, y = y, x
+	}
+	if x.Human {
+		if battle, ok := b.play(x, y, round); ok {
+			crownsX, crownsY = battle.Team[0].Crowns, battle.Opponent[0].Crowns
+			played = true
+		} else {
+			x.Human = false // The AI plays the rest of the human's matches
+		}
+	}
+	if !played {
+		state, _ := headlessMatch(b.Rules, b.rng.Int63(), x.Name, y.Name, b.deck(x), b.deck(y))
+		crownsX, crownsY = state.Crowns.Player, state.Crowns.Enemy
+	}
+
+	x.met[y.Tag], y.met[x.Tag] = true, true
+	x.Crowns += crownsX
+	y.Crowns += crownsY
+	switch {
+	case crownsX > crownsY:
+		x.Score++
+		x.Wins++
+		y.Losses++
+		b.announce(x, y, "%s beat %s %d-%d", x.Name, y.Name, crownsX, crownsY)
+		return x
+	case crownsY > crownsX:
+		y.Score++
+		y.Wins++
+		x.Losses++
+		b.announce(x, y, "%s beat %s %d-%d", y.Name, x.Name, crownsY, crownsX)
+		return y
+	}
+	x.Draws++
+	y.Draws++
+	b.announce(x, y, "%s and %s drew %d-%d", x.Name, y.Name, crownsX, crownsY)
+	return nil
+}
+
+// announce prints the result of a match, but only when it involves the human: AI
+// matches are summed up at the end of the round
+func (b *Bracket) announce(x, y *Entrant, format string, v ...interface{}) {
+	if x.Tag == b.Entrants[0].Tag || y.Tag == b.Entrants[0].Tag {
+		fmt.Printf(format+"\n", v...)
+	}
+}
+
+// roundDone prints where the human stands after a round
+func (b *Bracket) roundDone(round, rounds int) {
+	human := b.Entrants[0]
+	place := 0
+	for i, e := range b.ranked() {
+		if e == human {
+			place = i + 1
+		}
+	}
+	total := ""
+	if rounds > 0 {
+		total = fmt.Sprintf("/%d", rounds)
+	}
+	fmt.Printf("Round %d%s done: you are %d of %d with %d wins and %d losses\n",
+		round, total, place, len(b.Entrants), human.Wins, human.Losses)
+}
+
+// ranked orders the entrants by score, then crowns, then trophies
+func (b *Bracket) ranked() []*Entrant {
+	order := append([]*Entrant(nil), b.Entrants...)
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].Score != order[j].Score {
+			return order[i].Score > order[j].Score
+		}
+		if order[i].Crowns != order[j].Crowns {
+			return order[i].Crowns > order[j].Crowns
+		}
+		return order[i].Trophies > order[j].Trophies
+	})
+	return order
+}
+
+// Standings returns the final ranking in the shape the API reports tournament members
+func (b *Bracket) Standings() []clash.TournamentMember {
+	var standings []clash.TournamentMember
+	for i, e := range b.ranked() {
+		standings = append(standings, clash.TournamentMember{Tag: e.Tag, Name: e.Name, Score: e.Score, Rank: i + 1, Clan: e.Ref.Clan})
+	}
+	return standings
+}
+
+// headlessMatch plays a match between two AIs without drawing anything and returns
+// the final state and how it ended
+func headlessMatch(rules Ruleset, seed int64, playerName, enemyName string, playerDeck, enemyDeck []clash.Card) (*GameState, MatchEnd) {
+	match := newGameState(rules, seed, playerName, enemyName, playerDeck, enemyDeck)
+	state := &match
+	return state, simulateMatch(state, [2]Opponent{&ElixirOpponent{}, &ElixirOpponent{}})
+}
+
+// simulateMatch lets an opponent strategy play each side until the match ends
+func simulateMatch(state *GameState, sides [2]Opponent) MatchEnd {
+	for {
+		for _, side := range []Side{SidePlayer, SideEnemy} {
+			if move, ok := sides[side].Decide(state, side, state.strategyRandom(side)); ok {
+				playCard(state, side, move)
+			}
+		}
+		advanceMatch(state, tickInterval)
+		if end, _ := checkMatchEnd(state); end.Over {
+			return end
+		}
+	}
+}
+
+// printStandings prints the top of the standings and the human's place if they are
+// further down
+func printStandings(name string, standings []clash.TournamentMember, humanTag string, top int) {
+	fmt.Printf("\nFinal standings of %s:\n", name)
+	fmt.Printf("%4s  %-20s %-12s %5s\n", "Rank", "Name", "Tag", "Score")
+	for i, member := range standings {
+		you := clash.NormaliseTag(member.Tag) == clash.NormaliseTag(humanTag)
+		if i >= top && !you {
+			continue
+		}
+		if i >= top && you && i > top {
+			fmt.Println("  ...")
+		}
+		marker := ""
+		if you {
+			marker = "  <- you"
+		}
+		fmt.Printf("%4d  %-20s %-12s %5d%s\n", member.Rank, member.Name, member.Tag, member.Score, marker)
+	}
+}
+
+// askTournamentFormat lets the player pick a format, defaulting to the in-game one
+func askTournamentFormat(scanner *LineReader) string {
+	fmt.Println("Choose a format:")
+	for i, format := range tournamentFormats {
+		fmt.Printf("%d. %s\n", i+1, format)
+	}
+	fmt.Printf("Enter number (default %s): ", formatMostWins)
+	scanner.Scan()
+	if choice, err := parseInt(strings.TrimSpace(scanner.Text())); err == nil && choice >= 1 && choice <= len(tournamentFormats) {
+		return tournamentFormats[choice-1]
+	}
+	return formatMostWins
+}
+
+// localTournament builds a tournament between the local players
+func localTournament(players []clash.Player) clash.Tournament {
+	tournament := clash.Tournament{Name: "Local tournament", Duration: int(defaultTournamentDuration.Seconds())}
+	for i, p := range players {
+		tournament.MembersList = append(tournament.MembersList, clash.TournamentMember{Tag: p.Tag, Name: p.Name, Score: p.Trophies, Rank: i + 1, Clan: p.Clan})
+	}
+	tournament.Capacity = len(tournament.MembersList)
+	tournament.MaxCapacity = tournament.Capacity + 1
+	return tournament
+}
+
+// findTournament looks a tournament up by tag, or searches for it by name
+func findTournament(client clash.API, input string) (clash.Tournament, error) {
+	// Connects to tournaments.go: client.Tournament(tag).Get() and client.Tournaments().Search()
+	if tournament, err := client.Tournament(input).Get(); err == nil {
+		return tournament, nil
+	}
+	tournaments, err := client.Tournaments().Search(&clash.TournamentQuery{Name: input})
+	if err != nil {
+		return clash.Tournament{}, err
+	}
+	if len(tournaments.Items) == 0 {
+		return clash.Tournament{}, fmt.Errorf("no tournament found for %q", input)
+	}
+	// Search results leave out the members, so fetch the tournament itself
+	return client.Tournament(tournaments.Items[0].Tag).Get()
+}
+
+// playTournament runs a whole tournament for the player: one looked up through the API,
+// or one between the local players when nothing is entered. record keeps each of the
+// player's matches in their history, without trophies.
+func playTournament(scanner *LineReader, client clash.API, players *clash.Resolver, player clash.Player, locals []clash.Player,
+	rules Ruleset, logger *Logger, record func(replay *ReplayData, mode, replayPath string)) {
+	if len(locals) > 0 {
+		fmt.Print("Enter tournament tag (e.g., #XYZ123) or name to search, or nothing for a local tournament: ")
+	} else {
+		fmt.Print("Enter tournament tag (e.g., #XYZ123) or name to search: ")
+	}
+	scanner.Scan()
+	input := strings.TrimSpace(scanner.Text())
+	var tournament clash.Tournament
+	if input == "" && len(locals) > 0 {
+		tournament = localTournament(locals)
+	} else {
+		found, err := findTournament(client, input)
+		if err != nil {
+			logger.Error("Error fetching tournament: %v", err)
+			fmt.Println("Tournament not found. Returning to the menu.")
+			return
+		}
+		tournament = found
+	}
+
+	format := askTournamentFormat(scanner)
+	human := &Entrant{Tag: player.Tag, Name: player.Name, Trophies: player.Trophies, Deck: player.CurrentDeck, Human: true}
+	bracket, err := newBracket(tournament, format, rules, human, time.Now().UnixNano())
+	if err != nil {
+		fmt.Printf("%v. Returning to the menu.\n", err)
+		return
+	}
+	// Local players bring their own deck and trophies, as the API doesn't know them
+	byTag := map[string]clash.Player{}
+	for _, p := range locals {
+		byTag[clash.NormaliseTag(p.Tag)] = p
+	}
+	// Connects to refs.go: Every other member's profile is fetched in one batch up front
+	var tags []string
+	for _, e := range bracket.Entrants {
+		if local, ok := byTag[clash.NormaliseTag(e.Tag)]; ok && !e.Human {
+			e.Deck, e.Ref.Deck, e.Ref.Trophies = local.CurrentDeck, local.CurrentDeck, local.Trophies
+		} else if !e.Human {
+			tags = append(tags, e.Tag)
+		}
+	}
+	players.Prefetch(context.Background(), tags...)
+	bracket.deck = func(e *Entrant) []clash.Card {
+		if e.Deck == nil {
+			// Connects to decks.go: Members play their real deck when the API has it
+			deck, _, err := fetchOpponentDeck(players, e.Tag)
+			if err != nil || len(deck) == 0 {
+				logger.Error("Error fetching %s's deck: %v", e.Name, err)
+				deck = player.CurrentDeck
+			}
+			e.Deck = deck
+		}
+		return e.Deck
+	}
+	bracket.play = func(human, opponent *Entrant, round int) (clash.Battle, bool) {
+		// Their score seeds the bracket; the match is played against their real trophies
+		ref := withTrophies(players, opponent.Ref)
+		fmt.Printf("\nRound %d: you play %s (score %d, %d trophies).\n", round, opponent.Name, opponent.Score, ref.Trophies)
+		fmt.Print("Press Enter to play, or a to let the AI play your remaining matches: ")
+		scanner.Scan()
+		if strings.ToLower(strings.TrimSpace(scanner.Text())) == "a" {
+			return clash.Battle{}, false
+		}
+		replay := playGame(scanner, players, player, ref, rules, time.Now().UnixNano(), &ElixirOpponent{}, logger)
+		record(&replay, "tournament", storeReplay(&replay, logger))
+		return replay.Result, true
+	}
+
+	printStandings(bracket.Name, bracket.Run(), player.Tag, 10)
+}
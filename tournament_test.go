@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fiskie/go-clash/clash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBracket_Run(t *testing.T) {
+	rules := rulesets["ladder"]
+	tournament := clash.Tournament{
+		Name: "Test Cup",
+		// Two rounds of most-wins fit
+		Duration: int(2 * (rules.matchLength() + matchmakingTime).Seconds()),
+		MembersList: []clash.TournamentMember{
+			{Tag: "#9LL", Name: "B", Score: 40, Rank: 1},
+			{Tag: "#8QU", Name: "C", Score: 30, Rank: 2},
+			{Tag: "#2CC", Name: "D", Score: 20, Rank: 3},
+			{Tag: "#2RG", Name: "E", Score: 10, Rank: 4},
+		},
+	}
+	deck := testDeck()
+
+	for _, tt := range []struct {
+		format     string
+		humanScore int
+		totalScore int // Wins and byes of the whole field
+		games      int // Matches and byes each entrant has
+	}{
+		// Three rounds for five players: three byes, then four knockout matches
+		{formatElimination, 3, 7, 0},
+		// Three rounds, each with one bye and two matches
+		{formatSwiss, 3, -1, 3},
+		// Two rounds of two matches, and someone sits each round out
+		{formatMostWins, 2, -1, -1},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			human := &Entrant{Tag: "#2PP", Name: "A", Trophies: 5000, Human: true}
+			b, err := newBracket(tournament, tt.format, rules, human, 1)
+			assert.Nil(t, err)
+			assert.Len(t, b.Entrants, 5)
+
+			// The human wins every match they play
+			b.play = func(human, opponent *Entrant, round int) (clash.Battle, bool) {
+				return clash.Battle{
+					Team:     []clash.BattlePlayer{{Crowns: 1}},
+					Opponent: []clash.BattlePlayer{{Crowns: 0}},
+				}, true
+			}
+			b.deck = func(e *Entrant) []clash.Card { return deck }
+
+			standings := b.Run()
+			assert.Len(t, standings, 5)
+			assert.Equal(t, tt.humanScore, human.Score)
+			assert.Zero(t, human.Losses)
+
+			total := 0
+			for i, member := range standings {
+				assert.Equal(t, i+1, member.Rank)
+				if i > 0 {
+					assert.LessOrEqual(t, member.Score, standings[i-1].Score)
+				}
+				total += member.Score
+			}
+			if tt.totalScore >= 0 {
+				assert.Equal(t, tt.totalScore, total)
+			}
+			for _, e := range b.Entrants {
+				played := e.Wins + e.Losses + e.Draws
+				if tt.games > 0 {
+					bye := 0
+					if e.bye {
+						bye = 1
+					}
+					assert.Equal(t, tt.games, played+bye, e.Name)
+				}
+				if tt.format == formatMostWins {
+					assert.Equal(t, e.Wins, e.Score, e.Name)
+					assert.LessOrEqual(t, played, 2, e.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Tower represents a tower with stats as per TCR Appendix
+type Tower struct {
+	Type     string  `json:"type"`
+	HP       int     `json:"hp"`
+	ATK      int     `json:"atk"`
+	DEF      int     `json:"def"`
+	CRIT     float64 `json:"crit"` // Crit chance (0.1 for King, 0.05 for Guard)
+	MaxHP    int     `json:"max_hp"`
+	Lane     Lane    `json:"lane"`   // Lane covered by a guard tower, unused for the King Tower
+	Pos      float64 `json:"pos"`    // Distance along the lane from the player's back line
+	Active   bool    `json:"active"` // The King Tower sleeps until it is woken up
+	cooldown float64 // Seconds until the tower can shoot again
+}
+
+// Tower crits multiply the shot's damage
+const towerCritMultiplier = 1.5
+
+// isKing reports whether the tower is the King Tower
+func (t *Tower) isKing() bool {
+	return t.Type == "King Tower"
+}
+
+// coversLane reports whether the tower stands in a lane. The King Tower covers both.
+func (t *Tower) coversLane(lane Lane) bool {
+	return t.isKing() || t.Lane == lane
+}
+
+// attackRange returns how far the tower shoots, in tiles
+func (t *Tower) attackRange() float64 {
+	if t.isKing() {
+		return 7.0
+	}
+	return 7.5
+}
+
+// hitSpeed returns the seconds between two tower shots
+func (t *Tower) hitSpeed() float64 {
+	if t.isKing() {
+		return 2.0
+	}
+	return 1.5
+}
+
+// newTowers builds the three starting towers of a side. Guard towers start active,
+// the King Tower does not.
+func newTowers(side Side) []Tower {
+	return []Tower{
+		{Type: "Guard Tower 1", HP: 1000, ATK: 300, DEF: 100, CRIT: 0.05, MaxHP: 1000, Lane: LaneLeft, Pos: sidePosition(side, 6.5), Active: true},
+		{Type: "Guard Tower 2", HP: 1000, ATK: 300, DEF: 100, CRIT: 0.05, MaxHP: 1000, Lane: LaneRight, Pos: sidePosition(side, 6.5), Active: true},
+		{Type: "King Tower", HP: 2000, ATK: 500, DEF: 300, CRIT: 0.1, MaxHP: 2000, Pos: sidePosition(side, 3)},
+	}
+}
+
+// mitigate reduces incoming damage by the tower's DEF. Each point of DEF
+// absorbs a thousandth of the hit, with diminishing returns.
+func (t *Tower) mitigate(damage int) int {
+	return max(1, damage*1000/(1000+t.DEF))
+}
+
+// applyDamage applies damage to a single tower after DEF and describes its remaining HP.
+// It returns the damage actually dealt.
+func applyDamage(tower *Tower, damage int) (int, string) {
+	dealt := min(tower.mitigate(damage), tower.HP)
+	tower.HP -= dealt
+	return dealt, fmt.Sprintf("%s (HP now %d)", tower.Type, tower.HP)
+}
+
+// isKingTowerDestroyed checks if the King Tower is destroyed
+func isKingTowerDestroyed(towers []Tower) bool {
+	for _, tower := range towers {
+		if tower.isKing() && tower.HP <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// activateKing wakes up a side's King Tower once one of its guard towers has fallen or
+// the King Tower itself has been hit
+func activateKing(towers []Tower) bool {
+	var king *Tower
+	guardLost := false
+	for i := range towers {
+		if towers[i].isKing() {
+			king = &towers[i]
+		} else if towers[i].HP <= 0 {
+			guardLost = true
+		}
+	}
+	if king == nil || king.Active || king.HP <= 0 {
+		return false
+	}
+	if guardLost || king.HP < king.MaxHP {
+		king.Active = true
+		return true
+	}
+	return false
+}
+
+// stepTowers lets every active tower of a side shoot at the nearest enemy unit in range
+func stepTowers(state *GameState, side Side, dt float64) []string {
+	var events []string
+	towers := state.towers(side)
+	if activateKing(towers) {
+		events = append(events, fmt.Sprintf("%s's King Tower activated", state.sideName(side)))
+	}
+
+	for i := range towers {
+		tower := &towers[i]
+		if tower.HP <= 0 || !tower.Active {
+			continue
+		}
+		if tower.cooldown > 0 {
+			tower.cooldown -= dt
+			continue
+		}
+
+		target := towerTarget(state.Units, side, tower)
+		if target == nil {
+			continue
+		}
+		tower.cooldown = tower.hitSpeed()
+		damage := tower.ATK
+		if state.random().Float64() < tower.CRIT {
+			damage = int(float64(damage) * towerCritMultiplier)
+		}
+		events = append(events, damageUnit(state, side, tower.Type, target, damage)...)
+	}
+	return events
+}
+
+// towerTarget picks the closest enemy unit within a tower's range. Guard towers only
+// cover their own lane while the King Tower covers both.
+func towerTarget(units []*Unit, side Side, tower *Tower) *Unit {
+	var nearest *Unit
+	best := math.Inf(1)
+	for _, u := range units {
+		if u.HP <= 0 || u.Side == side {
+			continue
+		}
+		if !tower.coversLane(u.Lane) {
+			continue
+		}
+		if d := math.Abs(u.Pos - tower.Pos); d <= tower.attackRange() && d < best {
+			nearest, best = u, d
+		}
+	}
+	return nearest
+}
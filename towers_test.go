@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTower_Mitigate(t *testing.T) {
+	for _, tt := range []struct {
+		def, damage, taken int
+	}{
+		{0, 500, 500},
+		{100, 1000, 909},
+		{300, 1000, 769},
+		{300, 1, 1}, // Every hit does at least 1 damage
+	} {
+		tower := Tower{DEF: tt.def}
+		assert.Equal(t, tt.taken, tower.mitigate(tt.damage), "%d damage against %d DEF", tt.damage, tt.def)
+	}
+}
+
+func TestApplyDamage(t *testing.T) {
+	tower := Tower{Type: "Guard Tower 1", HP: 500, DEF: 100}
+	dealt, result := applyDamage(&tower, 1000)
+	assert.Equal(t, 500, dealt)
+	assert.Equal(t, 0, tower.HP)
+	assert.Equal(t, "Guard Tower 1 (HP now 0)", result)
+}
+
+func TestActivateKing(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		setup  func(towers []Tower)
+		woken  bool
+		active bool
+	}{
+		{"asleep", func(towers []Tower) {}, false, false},
+		{"guard tower lost", func(towers []Tower) { towers[1].HP = 0 }, true, true},
+		{"king hit", func(towers []Tower) { towers[2].HP-- }, true, true},
+		{"already awake", func(towers []Tower) { towers[2].Active, towers[0].HP = true, 0 }, false, true},
+		{"king destroyed", func(towers []Tower) { towers[2].HP = 0 }, false, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			towers := newTowers(SidePlayer)
+			tt.setup(towers)
+			assert.Equal(t, tt.woken, activateKing(towers))
+			assert.Equal(t, tt.active, towers[2].Active)
+		})
+	}
+}
+
+func TestTowerTarget(t *testing.T) {
+	// The enemy's towers stand at 25.5 (guards) and 29 (king)
+	towers := newTowers(SideEnemy)
+	for _, tt := range []struct {
+		name  string
+		tower int
+		units []*Unit
+		id    int
+	}{
+		{"nearest in lane", 0, []*Unit{
+			{ID: 1, Side: SidePlayer, Lane: LaneLeft, HP: 100, Pos: 19},
+			{ID: 2, Side: SidePlayer, Lane: LaneLeft, HP: 100, Pos: 21},
+		}, 2},
+		{"guard ignores the other lane", 0, []*Unit{
+			{ID: 1, Side: SidePlayer, Lane: LaneRight, HP: 100, Pos: 24},
+		}, 0},
+		{"king covers both lanes", 2, []*Unit{
+			{ID: 1, Side: SidePlayer, Lane: LaneRight, HP: 100, Pos: 24},
+		}, 1},
+		{"out of range", 0, []*Unit{
+			{ID: 1, Side: SidePlayer, Lane: LaneLeft, HP: 100, Pos: 17},
+		}, 0},
+		{"own and dead units", 0, []*Unit{
+			{ID: 1, Side: SideEnemy, Lane: LaneLeft, HP: 100, Pos: 24},
+			{ID: 2, Side: SidePlayer, Lane: LaneLeft, HP: 0, Pos: 24},
+		}, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			u := towerTarget(tt.units, SideEnemy, &towers[tt.tower])
+			if tt.id == 0 {
+				assert.Nil(t, u)
+				return
+			}
+			assert.Equal(t, tt.id, u.ID)
+		})
+	}
+}
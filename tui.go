@@ -0,0 +1,447 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ANSI escape sequences used by the terminal UI
+const (
+	ansiReset      = "\x1b[0m"
+	ansiBold       = "\x1b[1m"
+	ansiDim        = "\x1b[2m"
+	ansiReverse    = "\x1b[7m"
+	ansiRed        = "\x1b[31m"
+	ansiYellow     = "\x1b[33m"
+	ansiBlue       = "\x1b[34m"
+	ansiMagenta    = "\x1b[35m"
+	ansiCyan       = "\x1b[36m"
+	ansiHome       = "\x1b[H"
+	ansiClearLine  = "\x1b[K"
+	ansiClearBelow = "\x1b[J"
+	ansiAltScreen  = "\x1b[?1049h"
+	ansiMainScreen = "\x1b[?1049l"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+)
+
+// Arena grid layout: each row covers two tiles of both lanes
+const (
+	gridRows      = int(arenaLength / tilesPerRow)
+	tilesPerRow   = 2.0
+	laneCellWidth = 13
+	kingCellWidth = 5
+	gridWidth     = 2*laneCellWidth + kingCellWidth + 7 // Cells, borders and row labels
+	logLines      = 6
+	hpBarWidth    = 10
+)
+
+// frameInterval is how often the match screen is redrawn
+const frameInterval = 200 * time.Millisecond
+
+// TUI draws a match full-screen and turns key presses into card plays. Every frame is
+// written in one go over the previous one, so the screen doesn't flicker.
+type TUI struct {
+	out      io.Writer
+	keyboard *Keyboard
+	events   []string
+	selected int  // Hand slot picked with a number key, -1 when none
+	typing   bool // A command is being typed after ":"
+	line     string
+	message  string
+	help     string // Replaces the key help on the last line when set
+	quitting bool   // Surrender was asked for and waits for confirmation
+	screen   bool   // Stdout is a terminal the alternate screen was switched to
+	closed   bool
+}
+
+// uiCommand is what the player asked for with their last input
+type uiCommand struct {
+	Surrender bool
+	Move      Move
+}
+
+// newTUI switches the terminal to the full-screen match view. Input comes from the
+// keyboard, or from lines when stdin is not a terminal.
+func newTUI(lines *LineReader) *TUI {
+	t := &TUI{out: os.Stdout, keyboard: newKeyboard(lines), selected: -1, screen: isTerminal(int(os.Stdout.Fd()))}
+	if t.screen {
+		fmt.Fprint(t.out, ansiAltScreen+ansiHideCursor)
+	}
+	return t
+}
+
+// Keys returns the channel player input arrives on
+func (t *TUI) Keys() <-chan string {
+	return t.keyboard.Keys()
+}
+
+// Close restores the terminal. It is safe to call more than once.
+func (t *TUI) Close() {
+	if t.closed {
+		return
+	}
+	t.closed = true
+	t.keyboard.Close()
+	if t.screen {
+		fmt.Fprint(t.out, ansiShowCursor+ansiMainScreen)
+	}
+}
+
+// Log adds events to the scrolling event log
+func (t *TUI) Log(events ...string) {
+	t.events = append(t.events, events...)
+	if len(t.events) > logLines {
+		t.events = t.events[len(t.events)-logLines:]
+	}
+}
+
+// Say shows a status message under the hand until the next one
+func (t *TUI) Say(format string, v ...interface{}) {
+	t.message = fmt.Sprintf(format, v...)
+}
+
+// Command interprets player input. With single keys, a number picks a card and L/R or
+// the arrow keys deploy it, while ":" opens a command line for placing a card on a tile;
+// in line mode a whole command such as "3 L 4,10" is typed. Surrendering has to be
+// confirmed with "y". It returns false when the input didn't make a move.
+func (t *TUI) Command(input string, handLen int) (uiCommand, bool, error) {
+	if t.quitting {
+		t.quitting = false
+		if strings.EqualFold(input, "y") {
+			return uiCommand{Surrender: true}, true, nil
+		}
+		t.Say("Surrender cancelled")
+		return uiCommand{}, false, nil
+	}
+	if t.typing {
+		switch input {
+		case "enter":
+			t.typing = false
+			return t.typed(t.line, handLen)
+		case "esc", "ctrl-c":
+			t.typing = false
+		case "backspace":
+			if len(t.line) > 0 {
+				t.line = t.line[:len(t.line)-1]
+			}
+		default:
+			if len(input) == 1 {
+				t.line += input
+			}
+		}
+		return uiCommand{}, false, nil
+	}
+
+	switch input {
+	case "0", "q", "ctrl-c":
+		t.quitting = true
+		t.Say("Surrender? Press y to confirm, anything else to keep playing")
+		return uiCommand{}, false, nil
+	case "esc":
+		t.selected = -1
+		return uiCommand{}, false, nil
+	}
+	if !t.keyboard.Raw() {
+		return t.typed(input, handLen)
+	}
+
+	if input == ":" {
+		t.typing, t.line = true, ""
+		return uiCommand{}, false, nil
+	}
+	if choice, err := parseInt(input); err == nil {
+		if choice < 1 || choice > handLen {
+			return uiCommand{}, false, fmt.Errorf("pick a card from 1 to %d", handLen)
+		}
+		t.selected = choice - 1
+		return uiCommand{}, false, nil
+	}
+	var lane Lane
+	switch input {
+	case "l", "left":
+		lane = LaneLeft
+	case "r", "right":
+		lane = LaneRight
+	default:
+		return uiCommand{}, false, nil
+	}
+	if t.selected < 0 || t.selected >= handLen {
+		return uiCommand{}, false, fmt.Errorf("pick a card with 1-%d first", handLen)
+	}
+	slot := t.selected
+	t.selected = -1
+	return uiCommand{Move: Move{Slot: slot, Lane: lane}}, true, nil
+}
+
+// typed interprets a typed command such as "3 L 4,10"
+func (t *TUI) typed(line string, handLen int) (uiCommand, bool, error) {
+	choice, lane, tile, err := parseDeployCommand(line)
+	if err == nil && (choice < 1 || choice > handLen) {
+		err = fmt.Errorf("no card %d in your hand", choice)
+	}
+	if err != nil {
+		return uiCommand{}, false, fmt.Errorf("%v (try 3 L or 3 L 4,10)", err)
+	}
+	t.selected = -1
+	return uiCommand{Move: Move{Slot: choice - 1, Lane: lane, Tile: tile}}, true, nil
+}
+
+// Render draws the whole match screen
+func (t *TUI) Render(state *GameState) {
+	var b strings.Builder
+	b.WriteString(ansiHome)
+	line := func(s string) {
+		b.WriteString(s + ansiReset + ansiClearLine + "\n")
+	}
+
+	line(fmt.Sprintf("%s%s%s vs %s%s%s   %s   Crowns %s%d%s - %s%d%s",
+		ansiBold+ansiBlue, state.PlayerName, ansiReset, ansiBold+ansiRed, state.EnemyName, ansiReset,
+		timeLeft(state), ansiBlue, state.Crowns.Player, ansiReset, ansiRed, state.Crowns.Enemy, ansiReset))
+
+	arena := renderArena(state)
+	panel := renderTowerPanel(state)
+	for i := 0; i < len(arena) || i < len(panel); i++ {
+		row := strings.Repeat(" ", gridWidth)
+		if i < len(arena) {
+			row = arena[i]
+		}
+		if i < len(panel) {
+			row += "  " + panel[i]
+		}
+		line(row)
+	}
+
+	line(fmt.Sprintf("Elixir %s %.1f/%.0f   Opponent %.1f",
+		elixirBar(state.PlayerElixir, state.Rules.MaxElixir), state.PlayerElixir, state.Rules.MaxElixir, state.EnemyElixir))
+	line(t.renderHand(state))
+	if t.typing {
+		line(ansiYellow + ":" + t.line + "_")
+	} else {
+		line(ansiYellow + t.message)
+	}
+	line(ansiDim + strings.Repeat("─", gridWidth))
+	for i := 0; i < logLines; i++ {
+		if i < len(t.events) {
+			line(t.events[i])
+		} else {
+			line("")
+		}
+	}
+	if t.help != "" {
+		line(ansiDim + t.help)
+	} else if t.keyboard.Raw() {
+		line(ansiDim + "1-4 pick a card · L/R or ←/→ deploy it · : place on a tile (3 L 4,10) · Esc cancel · 0/q surrender")
+	} else {
+		line(ansiDim + "Type a card, a lane and optionally a tile, then Enter (e.g. 3 L or 3 L 4,10) · 0 surrender")
+	}
+	b.WriteString(ansiClearBelow)
+	fmt.Fprint(t.out, b.String())
+}
+
+// renderHand shows the hand with the picked card highlighted and unaffordable cards dimmed
+func (t *TUI) renderHand(state *GameState) string {
+	if state.PlayerCycle == nil {
+		return ""
+	}
+	parts := []string{"Hand"}
+	for i, card := range state.PlayerCycle.Hand {
+		cost := lookupCardStats(card.Name).ElixirCost
+		style := ""
+		if float64(cost) > state.PlayerElixir {
+			style = ansiDim
+		}
+		if i == t.selected {
+			style = ansiReverse + ansiBold
+		}
+		parts = append(parts, fmt.Sprintf("%s[%d] %s %s(%d)%s", style, i+1, card.Name, ansiMagenta, cost, ansiReset))
+	}
+	if next, ok := state.PlayerCycle.Next(); ok {
+		parts = append(parts, ansiDim+"Next: "+next.Name+ansiReset)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// cellToken is a coloured piece of text in an arena cell
+type cellToken struct {
+	text  string
+	color string
+}
+
+// renderArena draws both lanes from the opponent's back line at the top to the
+// player's at the bottom, with the King Towers in the middle column
+func renderArena(state *GameState) []string {
+	left := make([][]cellToken, gridRows)
+	king := make([][]cellToken, gridRows)
+	right := make([][]cellToken, gridRows)
+	row := func(pos float64) int {
+		r := int((arenaLength - pos) / tilesPerRow)
+		return max(0, min(gridRows-1, r))
+	}
+	cells := func(lane Lane) [][]cellToken {
+		if lane == LaneRight {
+			return right
+		}
+		return left
+	}
+
+	for _, side := range []Side{SideEnemy, SidePlayer} {
+		color := sideColor(side)
+		for _, tower := range state.towers(side) {
+			token := cellToken{"[G]", color}
+			if tower.isKing() {
+				token.text = "[K]"
+				if !tower.Active {
+					token.text = "[k]"
+				}
+			}
+			if tower.HP <= 0 {
+				token = cellToken{"[x]", ansiDim}
+			}
+			if tower.isKing() {
+				king[row(tower.Pos)] = append(king[row(tower.Pos)], token)
+			} else {
+				lane := cells(tower.Lane)
+				lane[row(tower.Pos)] = append(lane[row(tower.Pos)], token)
+			}
+		}
+	}
+
+	// Group units of the same card and side in a cell, like "Ar2"
+	type group struct {
+		row  int
+		lane Lane
+		side Side
+		name string
+	}
+	counts := map[group]int{}
+	var order []group
+	for _, u := range state.Units {
+		if u.HP <= 0 {
+			continue
+		}
+		g := group{row(u.Pos), u.Lane, u.Side, u.Card.Name}
+		if counts[g] == 0 {
+			order = append(order, g)
+		}
+		counts[g]++
+	}
+	for _, g := range order {
+		text := unitLabel(g.name)
+		if counts[g] > 1 {
+			text += fmt.Sprint(counts[g])
+		}
+		lane := cells(g.lane)
+		lane[g.row] = append(lane[g.row], cellToken{text, sideColor(g.side)})
+	}
+
+	border := func(l, m, r string) string {
+		return ansiDim + l + strings.Repeat("─", laneCellWidth) + m + strings.Repeat("─", kingCellWidth) + m +
+			strings.Repeat("─", laneCellWidth) + r + ansiReset
+	}
+	// Rows are labelled with the lowest tile row they cover, in the player's coordinates
+	lines := []string{"   " + border("┌", "┬", "┐")}
+	for r := 0; r < gridRows; r++ {
+		lowest := int(arenaLength) - int(tilesPerRow)*(r+1)
+		fill := " "
+		if lowest+int(tilesPerRow) > riverStart && lowest <= riverEnd {
+			fill = "~"
+		}
+		label := fmt.Sprintf("%s%2d %s", ansiDim, lowest, ansiReset)
+		lines = append(lines, label+ansiDim+"│"+ansiReset+renderCell(left[r], laneCellWidth, fill)+
+			ansiDim+"│"+ansiReset+renderCell(king[r], kingCellWidth, fill)+
+			ansiDim+"│"+ansiReset+renderCell(right[r], laneCellWidth, fill)+ansiDim+"│"+ansiReset)
+	}
+	return append(lines, "   "+border("└", "┴", "┘"))
+}
+
+// renderCell lays out tokens in a cell of a fixed width, padding with fill and marking
+// cells too full to show everything with a "+"
+func renderCell(tokens []cellToken, width int, fill string) string {
+	var b strings.Builder
+	used := 0
+	for i, token := range tokens {
+		text := token.text
+		if i > 0 {
+			text = " " + text
+		}
+		if used+len(text) > width {
+			if used < width {
+				b.WriteString("+")
+				used++
+			}
+			break
+		}
+		b.WriteString(token.color + text + ansiReset)
+		used += len(text)
+	}
+	if used < width {
+		b.WriteString(ansiCyan + strings.Repeat(fill, width-used) + ansiReset)
+	}
+	return b.String()
+}
+
+// renderTowerPanel lists both sides' towers with HP bars, opponent first
+func renderTowerPanel(state *GameState) []string {
+	lines := []string{ansiBold + ansiRed + state.EnemyName}
+	for _, tower := range state.EnemyTowers {
+		lines = append(lines, towerLine(tower, ansiRed))
+	}
+	lines = append(lines, "")
+	lines = append(lines, ansiDim+"Units: "+ansiBlue+"yours"+ansiReset+ansiDim+", "+ansiRed+"opponent's"+ansiReset)
+	lines = append(lines, ansiDim+"[G] Guard Tower  [K] King Tower  [k] asleep")
+	lines = append(lines, ansiDim+"Tiles: x 0-8 left lane, x 9-17 right lane")
+	lines = append(lines, ansiDim+"y 0-14 your side, 15-16 river, 17-31 theirs")
+	for len(lines) < gridRows-3 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, ansiBold+ansiBlue+state.PlayerName)
+	for _, tower := range state.PlayerTowers {
+		lines = append(lines, towerLine(tower, ansiBlue))
+	}
+	return lines
+}
+
+// towerLine shows a tower's HP as a bar
+func towerLine(tower Tower, color string) string {
+	return fmt.Sprintf("%-13s %s %4d/%d%s", tower.Type, hpBar(tower.HP, tower.MaxHP, color), max(0, tower.HP), tower.MaxHP, towerStatus(tower))
+}
+
+// hpBar draws hit points as a bar of hpBarWidth blocks
+func hpBar(hp, maxHP int, color string) string {
+	filled := 0
+	if maxHP > 0 && hp > 0 {
+		filled = max(1, hp*hpBarWidth/maxHP)
+	}
+	return color + strings.Repeat("█", filled) + ansiDim + strings.Repeat("░", hpBarWidth-filled) + ansiReset
+}
+
+// elixirBar draws one block per elixir up to the maximum
+func elixirBar(elixir, maxElixir float64) string {
+	filled := int(elixir)
+	return ansiMagenta + strings.Repeat("█", filled) + ansiDim + strings.Repeat("░", max(0, int(maxElixir)-filled)) + ansiReset
+}
+
+// sideColor is blue for the player and red for the opponent
+func sideColor(side Side) string {
+	if side == SidePlayer {
+		return ansiBlue
+	}
+	return ansiRed
+}
+
+// unitLabel abbreviates a card name to two letters, e.g. "Ar" for Archers and "FS" for Fire Spirit
+func unitLabel(name string) string {
+	name = strings.ReplaceAll(name, ".", "")
+	words := strings.Fields(name)
+	if len(words) > 1 {
+		return strings.ToUpper(words[0][:1] + words[1][:1])
+	}
+	if len(name) < 2 {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + strings.ToLower(name[1:2])
+}
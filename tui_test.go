@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTUI_Command(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		raw     bool
+		inputs  []string
+		command uiCommand
+		ok      bool // Of the last input
+	}{
+		{"pick and deploy", true, []string{"2", "r"}, uiCommand{Move: Move{Slot: 1, Lane: LaneRight}}, true},
+		{"arrow keys", true, []string{"4", "left"}, uiCommand{Move: Move{Slot: 3, Lane: LaneLeft}}, true},
+		{"tile", true, []string{":", "1", " ", "4", ",", "9", "enter"}, uiCommand{Move: Move{Slot: 0, Lane: LaneLeft, Tile: &Tile{4, 9}}}, true},
+		{"typed line", false, []string{"3 R"}, uiCommand{Move: Move{Slot: 2, Lane: LaneRight}}, true},
+		{"surrender asks first", true, []string{"q"}, uiCommand{}, false},
+		{"surrender confirmed", true, []string{"ctrl-c", "y"}, uiCommand{Surrender: true}, true},
+		{"surrender cancelled", true, []string{"0", "n", "2"}, uiCommand{}, false},
+		{"surrender confirmed by line", false, []string{"0", "Y"}, uiCommand{Surrender: true}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ui := &TUI{keyboard: &Keyboard{raw: tt.raw}, selected: -1}
+			var command uiCommand
+			var ok bool
+			for _, input := range tt.inputs {
+				var err error
+				command, ok, err = ui.Command(input, handSize)
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.command, command)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	// Connects to players.go: Units are spawned from a clash.Card
+	"github.com/fiskie/go-clash/clash"
+)
+
+// Lane identifies which half of the arena a unit walks down
+type Lane int
+
+const (
+	LaneLeft Lane = iota
+	LaneRight
+)
+
+func (l Lane) String() string {
+	if l == LaneRight {
+		return "right"
+	}
+	return "left"
+}
+
+// parseLane converts user input such as "L" or "right" into a Lane
+func parseLane(s string) (Lane, error) {
+	switch strings.ToLower(s) {
+	case "l", "left":
+		return LaneLeft, nil
+	case "r", "right":
+		return LaneRight, nil
+	}
+	return LaneLeft, fmt.Errorf("unknown lane %q (use L or R)", s)
+}
+
+// Side identifies who owns a unit or tower
+type Side int
+
+const (
+	SidePlayer Side = iota
+	SideEnemy
+)
+
+// opposite returns the other side of the arena
+func (s Side) opposite() Side {
+	if s == SidePlayer {
+		return SideEnemy
+	}
+	return SidePlayer
+}
+
+// Arena geometry, measured in tiles along a lane from the player's back line
+const (
+	arenaLength    = 32.0
+	deployDistance = 11.0 // Troops are dropped just in front of their own princess towers
+	sightRange     = 5.5  // Units engage enemy units closer than this
+	towerRadius    = 1.5
+	unitRadius     = 0.5
+	tickInterval   = 100 * time.Millisecond
+)
+
+// Unit is a deployed troop walking down a lane
+type Unit struct {
+	ID       int
+	Card     clash.Card
+	Stats    CardStats
+	Side     Side
+	Lane     Lane
+	HP       int
+	MaxHP    int
+	Pos      float64 // Distance along the lane from the player's back line
+	Stunned  float64 // Seconds until the unit can move and attack again
+	cooldown float64 // Seconds until the unit can attack again
+	decay    float64 // Hit points a building lost to decay that are not yet applied
+	spawn    float64 // Seconds until a spawner building produces its next wave
+	collect  float64 // Seconds until an elixir collector produces its next elixir
+}
+
+// isBuilding reports whether the unit is a building standing still in its lane
+func (u *Unit) isBuilding() bool {
+	return u.Stats.Type == CardBuilding
+}
+
+// sidePosition mirrors a distance measured from a side's own back line onto the lane
+func sidePosition(side Side, distance float64) float64 {
+	if side == SidePlayer {
+		return distance
+	}
+	return arenaLength - distance
+}
+
+// levelHitPoints scales a card's hit points by its level, 5% per level
+func levelHitPoints(card clash.Card, stats CardStats) int {
+	return stats.HitPoints + (card.Level-1)*stats.HitPoints/20
+}
+
+// critLabel describes which crits applied to a hit
+func critLabel(cardCrit, towerCrit bool) string {
+	if cardCrit && towerCrit {
+		return " (Double CRIT)"
+	} else if cardCrit {
+		return " (Card CRIT)"
+	} else if towerCrit {
+		return " (Tower CRIT)"
+	}
+	return ""
+}
+
+// deployCard puts a card into play for a side and returns a replay line describing it
+func deployCard(state *GameState, side Side, card clash.Card, lane Lane, tile *Tile) string {
+	stats := lookupCardStats(card.Name)
+	switch stats.Type {
+	case CardSpell:
+		return castSpell(state, side, card, stats, lane, tile)
+	case CardBuilding:
+		return placeBuilding(state, side, card, stats, lane, tile)
+	}
+
+	distance := deployDistance
+	if tile != nil {
+		distance = tile.distance()
+	}
+	count := max(1, stats.Count)
+	spawnUnits(state, side, card, stats, lane, sidePosition(side, distance), count)
+	troops := card.Name
+	if count > 1 {
+		troops = fmt.Sprintf("%d x %s", count, card.Name)
+	}
+	return fmt.Sprintf("%s deployed %s (Level %d) %s", state.sideName(side), troops, card.Level, placement(lane, tile))
+}
+
+// spawnUnits adds count units of a card to a lane at pos and returns them. Groups are
+// spread out slightly behind pos, away from the enemy.
+func spawnUnits(state *GameState, side Side, card clash.Card, stats CardStats, lane Lane, pos float64, count int) []*Unit {
+	behind := -unitRadius
+	if side == SideEnemy {
+		behind = unitRadius
+	}
+	spawned := make([]*Unit, 0, count)
+	for i := 0; i < count; i++ {
+		state.nextUnitID++
+		hp := levelHitPoints(card, stats)
+		spawned = append(spawned, &Unit{
+			ID:    state.nextUnitID,
+			Card:  card,
+			Stats: stats,
+			Side:  side,
+			Lane:  lane,
+			HP:    hp,
+			MaxHP: hp,
+			Pos:   math.Max(0, math.Min(arenaLength, pos+float64(i)*behind)),
+		})
+	}
+	state.Units = append(state.Units, spawned...)
+	return spawned
+}
+
+// laneTower returns the tower a unit pushing down a lane attacks: the lane's guard tower
+// while it stands, then the King Tower
+func laneTower(towers []Tower, lane Lane) *Tower {
+	var king *Tower
+	for i := range towers {
+		if towers[i].HP <= 0 {
+			continue
+		}
+		if towers[i].isKing() {
+			king = &towers[i]
+		} else if towers[i].Lane == lane {
+			return &towers[i]
+		}
+	}
+	return king
+}
+
+// nearestEnemyUnit returns the closest living enemy unit in the same lane within range.
+// Units that only target buildings ignore troops.
+func nearestEnemyUnit(units []*Unit, side Side, lane Lane, pos float64, reach float64, buildingsOnly bool) *Unit {
+	var nearest *Unit
+	best := math.Inf(1)
+	for _, other := range units {
+		if other.HP <= 0 || other.Side == side || other.Lane != lane {
+			continue
+		}
+		if buildingsOnly && !other.isBuilding() {
+			continue
+		}
+		if d := math.Abs(other.Pos - pos); d <= reach && d < best {
+			nearest, best = other, d
+		}
+	}
+	return nearest
+}
+
+// stepBattle advances every unit and tower by dt seconds and returns what happened
+func stepBattle(state *GameState, dt float64) []string {
+	events := stepEffects(state, dt)
+	for _, u := range state.Units {
+		if u.HP > 0 {
+			events = append(events, stepUnit(state, u, dt)...)
+		}
+	}
+	events = append(events, stepTowers(state, SidePlayer, dt)...)
+	events = append(events, stepTowers(state, SideEnemy, dt)...)
+
+	alive := state.Units[:0]
+	for _, u := range state.Units {
+		if u.HP > 0 {
+			alive = append(alive, u)
+		}
+	}
+	state.Units = alive
+	return events
+}
+
+// stepUnit moves a unit towards its target and attacks once it is in range
+func stepUnit(state *GameState, u *Unit, dt float64) []string {
+	if u.Stunned > 0 {
+		u.Stunned -= dt
+		return nil
+	}
+	if u.cooldown > 0 {
+		u.cooldown -= dt
+	}
+
+	var events []string
+	if u.isBuilding() {
+		events = stepBuilding(state, u, dt)
+		if u.HP <= 0 || u.Stats.BaseDamage == 0 {
+			return events
+		}
+	}
+
+	// Enemy units in sight take priority over towers, which lets buildings pull troops
+	if enemy := nearestEnemyUnit(state.Units, u.Side, u.Lane, u.Pos, sightRange, u.Stats.TargetsBuildings); enemy != nil {
+		reach := u.Stats.Range + unitRadius
+		if !approach(u, enemy.Pos, reach, dt) || u.cooldown > 0 {
+			return events
+		}
+		u.cooldown = u.Stats.HitSpeed
+		return append(events, damageUnit(state, u.Side, u.Card.Name, enemy, levelDamage(u.Card, u.Stats))...)
+	}
+
+	target := laneTower(state.towers(u.Side.opposite()), u.Lane)
+	if target == nil {
+		return events
+	}
+	if !approach(u, target.Pos, u.Stats.Range+towerRadius, dt) || u.cooldown > 0 {
+		return events
+	}
+	u.cooldown = u.Stats.HitSpeed
+	damage, cardCrit, towerCrit := calculateDamage(state.random(), u.Card, u.Stats, []Tower{*target})
+	dealt, result := applyDamage(target, damage)
+	if target.HP <= 0 {
+		events = append(events, fmt.Sprintf("%s's %s dealt %d damage%s and destroyed %s",
+			state.sideName(u.Side), u.Card.Name, dealt, critLabel(cardCrit, towerCrit), result))
+	}
+	return events
+}
+
+// damageUnit deals damage to a unit and returns an event if the hit defeated it
+func damageUnit(state *GameState, side Side, attacker string, target *Unit, damage int) []string {
+	if target.HP <= 0 {
+		return nil
+	}
+	target.HP -= damage
+	if target.HP > 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s's %s defeated %s's %s",
+		state.sideName(side), attacker, state.sideName(target.Side), target.Card.Name)}
+}
+
+// approach walks a unit towards pos and reports whether it is within reach of it
+func approach(u *Unit, pos float64, reach float64, dt float64) bool {
+	distance := math.Abs(pos - u.Pos)
+	if distance <= reach {
+		return true
+	}
+	step := math.Min(u.Stats.Speed*dt, distance-reach)
+	if pos > u.Pos {
+		u.Pos += step
+	} else {
+		u.Pos -= step
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLane(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		lane  Lane
+		err   bool
+	}{
+		{"L", LaneLeft, false},
+		{"left", LaneLeft, false},
+		{"R", LaneRight, false},
+		{"Right", LaneRight, false},
+		{"middle", LaneLeft, true},
+	} {
+		lane, err := parseLane(tt.input)
+		assert.Equal(t, tt.err, err != nil, tt.input)
+		assert.Equal(t, tt.lane, lane, tt.input)
+	}
+}
+
+func TestLaneTower(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		destroyed []int // Indexes into newTowers
+		lane      Lane
+		tower     string
+	}{
+		{"left guard", nil, LaneLeft, "Guard Tower 1"},
+		{"right guard", nil, LaneRight, "Guard Tower 2"},
+		{"king once the guard falls", []int{0}, LaneLeft, "King Tower"},
+		{"other lane's guard still up", []int{0}, LaneRight, "Guard Tower 2"},
+		{"nothing left", []int{0, 1, 2}, LaneLeft, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			towers := newTowers(SideEnemy)
+			for _, i := range tt.destroyed {
+				towers[i].HP = 0
+			}
+			tower := laneTower(towers, tt.lane)
+			if tt.tower == "" {
+				assert.Nil(t, tower)
+				return
+			}
+			assert.Equal(t, tt.tower, tower.Type)
+		})
+	}
+}
+
+func TestApproach(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		pos     float64
+		target  float64
+		reached bool
+		after   float64
+	}{
+		{"walks forward", 11, 25, false, 12},
+		{"walks back", 20, 5, false, 19},
+		{"stops at reach", 22.5, 25, false, 23},
+		{"in reach", 23, 25, true, 23},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &Unit{Stats: CardStats{Speed: 1}, Pos: tt.pos}
+			assert.Equal(t, tt.reached, approach(u, tt.target, 2, 1))
+			assert.InDelta(t, tt.after, u.Pos, 1e-9)
+		})
+	}
+}
+
+func TestNearestEnemyUnit(t *testing.T) {
+	units := []*Unit{
+		{ID: 1, Side: SidePlayer, Lane: LaneLeft, HP: 100, Pos: 14},
+		{ID: 2, Side: SideEnemy, Lane: LaneLeft, HP: 100, Pos: 18},
+		{ID: 3, Side: SideEnemy, Lane: LaneLeft, HP: 100, Pos: 16},
+		{ID: 4, Side: SideEnemy, Lane: LaneRight, HP: 100, Pos: 14},
+		{ID: 5, Side: SideEnemy, Lane: LaneLeft, HP: 0, Pos: 14},
+		{ID: 6, Side: SideEnemy, Lane: LaneLeft, HP: 100, Pos: 19, Stats: CardStats{Type: CardBuilding}},
+	}
+	for _, tt := range []struct {
+		name          string
+		side          Side
+		lane          Lane
+		reach         float64
+		buildingsOnly bool
+		id            int
+	}{
+		{"closest in lane", SidePlayer, LaneLeft, sightRange, false, 3},
+		{"out of reach", SidePlayer, LaneLeft, 1, false, 0},
+		{"other lane", SidePlayer, LaneRight, sightRange, false, 4},
+		{"other side", SideEnemy, LaneLeft, sightRange, false, 1},
+		{"buildings only", SidePlayer, LaneLeft, sightRange, true, 6},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			u := nearestEnemyUnit(units, tt.side, tt.lane, 14, tt.reach, tt.buildingsOnly)
+			if tt.id == 0 {
+				assert.Nil(t, u)
+				return
+			}
+			assert.Equal(t, tt.id, u.ID)
+		})
+	}
+}